@@ -5,15 +5,24 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/anurse/gogb/pkg/gogb"
+	"github.com/anurse/gogb/pkg/gogb/logging"
+	"github.com/anurse/gogb/pkg/gogb/save"
 	"github.com/jessevdk/go-flags"
 )
 
 func main() {
 	var opts struct {
-		Verbose    []bool `short:"v" long:"verbose" description:"Show verbose logging information."`
-		Positional struct {
+		Verbose     []bool `short:"v" long:"verbose" description:"Show verbose logging information."`
+		Strict      bool   `long:"strict" description:"Lint the header against the rules for licensed cartridges and report every anomaly found."`
+		WillItBoot  bool   `long:"will-it-boot" description:"Check whether the ROM would pass the real boot ROM's logo and checksum checks."`
+		ConvertSave string `long:"convert-save" value-name:"SAV" description:"Convert a save file for the ROM to the layout given by --save-layout instead of dumping the ROM."`
+		SaveLayout  string `long:"save-layout" choice:"raw" choice:"emulator" choice:"flashcart" default:"raw" description:"The layout to convert the save file to."`
+		Output      string `short:"o" long:"output" value-name:"FILE" description:"Where to write the converted save file. Defaults to SAV with the layout name inserted before the extension."`
+		Positional  struct {
 			Files []string `required:"1" positional-arg-name:"ROM"`
 		} `positional-args:"yes"`
 	}
@@ -28,16 +37,83 @@ func main() {
 		}
 	}
 
+	logging.SetDefaultLevel(logging.Verbosity(len(opts.Verbose)))
+
 	if len(opts.Positional.Files) == 0 {
 		panic("You must provide at least one ROM to dump!")
 	}
 
+	if opts.ConvertSave != "" {
+		if len(opts.Positional.Files) != 1 {
+			panic("You must provide exactly one ROM when converting a save file!")
+		}
+		convertSave(opts.Positional.Files[0], opts.ConvertSave, opts.SaveLayout, opts.Output)
+		return
+	}
+
+	// Keep going after a bad ROM so every file is reported, but fail overall
+	failed := false
 	for _, file := range opts.Positional.Files {
-		dumpRom(file)
+		if !dumpRom(file, opts.Strict, opts.WillItBoot) {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func convertSave(romFile string, saveFile string, layoutName string, output string) {
+	layout, err := save.ParseLayout(layoutName)
+	if err != nil {
+		panic(err)
+	}
+
+	content, err := ioutil.ReadFile(romFile)
+	if err != nil {
+		panic(err)
+	}
+	if len(content) < 0x0150 {
+		logging.For(logging.Cartridge).Error("file is too small to contain a cartridge header", "file", romFile, "size", len(content))
+		os.Exit(1)
+	}
+
+	var header gogb.CartridgeHeader
+	err = gogb.ParseHeader(content[0x0100:0x0150], &header)
+	if err != nil && !errors.Is(err, gogb.ErrHeaderChecksumInvalid) {
+		panic(err)
+	}
+
+	// MBC2 has 512 half-bytes of RAM built in, which the header doesn't declare
+	ramSize := header.RAMSize.Bytes()
+	if header.Type == gogb.Mbc2 || header.Type == gogb.Mbc2Battery {
+		ramSize = 512
 	}
+
+	data, err := ioutil.ReadFile(saveFile)
+	if err != nil {
+		panic(err)
+	}
+	converted, err := save.Convert(data, ramSize, layout)
+	if err != nil {
+		panic(err)
+	}
+
+	if output == "" {
+		ext := filepath.Ext(saveFile)
+		output = strings.TrimSuffix(saveFile, ext) + "." + layout.String() + ext
+	}
+	if err := ioutil.WriteFile(output, converted, 0644); err != nil {
+		panic(err)
+	}
+	fmt.Printf("Wrote %s save (%d bytes) to %s\n", layout, len(converted), output)
 }
 
-func dumpRom(file string) {
+// dumpRom prints the header of a ROM file. It returns false if the file isn't a ROM at all.
+func dumpRom(file string, strict bool, willItBoot bool) bool {
+	log := logging.For(logging.Cartridge)
+	log.Debug("reading ROM", "file", file)
+
 	content, err := ioutil.ReadFile(file)
 	if err != nil {
 		panic(err)
@@ -45,16 +121,28 @@ func dumpRom(file string) {
 
 	fmt.Println("Rom file ", file)
 
+	if len(content) < 0x0150 {
+		log.Error("file is too small to contain a cartridge header", "file", file, "size", len(content))
+		return false
+	}
+
 	// Slice the header and parse it
+	log.Debug("parsing header", "file", file, "strict", strict)
 	var header gogb.CartridgeHeader
 	headerBytes := content[0x0100:0x0150]
-	err = gogb.ParseHeader(headerBytes, &header)
-	if errors.Is(err, gogb.ErrHeaderChecksumInvalid) {
-		fmt.Fprintln(os.Stderr, "  Warning: Header checksum validation failed.")
+	err = gogb.ParseHeaderWithOptions(headerBytes, &header, gogb.ParseHeaderOptions{Strict: strict})
+	if anomalies := gogb.HeaderAnomalies(err); anomalies != nil {
+		for _, anomaly := range anomalies {
+			log.Warn("header anomaly", "file", file, "kind", anomaly.Kind, "addr", fmt.Sprintf("0x%04X", anomaly.Addr), "message", anomaly.Message)
+		}
 	} else if err != nil {
 		panic(err)
 	}
 
+	if willItBoot {
+		printBootVerdict(&header)
+	}
+
 	fmt.Printf("  Size: 0x%X4\n", len(content))
 	fmt.Println("  Title:", header.Title)
 	fmt.Println("  Manufacturer Code:", header.ManufacturerCode)
@@ -63,8 +151,8 @@ func dumpRom(file string) {
 	fmt.Println("  Old Licensee Code:", header.OldLicenseeCode)
 	fmt.Println("  Super GameBoy Support:", header.SGBSupport)
 	fmt.Println("  Type:", header.Type)
-	fmt.Printf("  ROM Size: %dKB\n", header.ROMSize)
-	fmt.Printf("  RAM Size: %dKB\n", header.RAMSize)
+	fmt.Println("  ROM Size:", header.ROMSize)
+	fmt.Println("  RAM Size:", header.RAMSize)
 	fmt.Println("  Japanese?:", header.Japanese)
 	fmt.Println("  Version:", header.VersionNumber)
 
@@ -81,4 +169,23 @@ func dumpRom(file string) {
 		fmt.Println("  Cartridge Checksum NOT VERIFIED")
 		fmt.Printf("    Expected: 0x%X4, Actual 0x%X4\n", header.GlobalChecksum, actualChecksum)
 	}
+	return true
+}
+
+func printBootVerdict(header *gogb.CartridgeHeader) {
+	verdict := gogb.CheckBoot(header)
+	switch {
+	case verdict.Boots():
+		fmt.Println("  Will it boot: YES")
+	case verdict.BootsOnCGB:
+		fmt.Println("  Will it boot: CGB ONLY")
+	default:
+		fmt.Println("  Will it boot: NO")
+	}
+	for _, problem := range verdict.Problems {
+		fmt.Println("    Problem:", problem)
+	}
+	for _, warning := range verdict.Warnings {
+		fmt.Println("    Warning:", warning)
+	}
 }
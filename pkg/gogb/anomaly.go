@@ -0,0 +1,132 @@
+package gogb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParseHeaderOptions control how strictly ParseHeaderWithOptions validates a cartridge header.
+type ParseHeaderOptions struct {
+	// In strict mode, the header must also follow the rules laid out for licensed cartridges:
+	// the logo must match, the title must be uppercase ASCII padded with zeros, the new licensee code
+	// must be two ASCII characters when the old licensee code defers to it, and the cartridge type
+	// and ROM/RAM size codes must be known values.
+	// Lenient mode (the default) only checks the header checksum, since that is all the boot ROM checks after the logo.
+	Strict bool
+}
+
+// An AnomalyKind identifies the kind of problem found in a cartridge header.
+type AnomalyKind uint8
+
+// Values for AnomalyKind
+const (
+	AnomalyHeaderChecksum AnomalyKind = iota
+	AnomalyLogo
+	AnomalyTitle
+	AnomalyLicenseeCode
+	AnomalyCartridgeType
+	AnomalyROMSize
+	AnomalyRAMSize
+)
+
+func (k AnomalyKind) String() string {
+	return [...]string{"HeaderChecksum", "Logo", "Title", "LicenseeCode", "CartridgeType", "ROMSize", "RAMSize"}[k]
+}
+
+// An Anomaly is a single problem found in a cartridge header.
+type Anomaly struct {
+	Kind AnomalyKind
+
+	// The ROM address of the offending data.
+	Addr int
+
+	// A human-readable description of the problem.
+	Message string
+}
+
+func (a Anomaly) String() string {
+	return fmt.Sprintf("0x%04X: %s", a.Addr, a.Message)
+}
+
+// A HeaderError is returned by ParseHeaderWithOptions when one or more anomalies are found.
+// errors.Is(err, ErrHeaderChecksumInvalid) reports whether one of them is a checksum mismatch.
+type HeaderError struct {
+	Anomalies []Anomaly
+}
+
+func (e *HeaderError) Error() string {
+	msgs := make([]string, len(e.Anomalies))
+	for i, a := range e.Anomalies {
+		msgs[i] = a.String()
+	}
+	return fmt.Sprintf("cartridge header has %d anomalies: %s", len(e.Anomalies), strings.Join(msgs, "; "))
+}
+
+// Is returns true if target is ErrHeaderChecksumInvalid and the header checksum did not match.
+func (e *HeaderError) Is(target error) bool {
+	if target != ErrHeaderChecksumInvalid {
+		return false
+	}
+	for _, a := range e.Anomalies {
+		if a.Kind == AnomalyHeaderChecksum {
+			return true
+		}
+	}
+	return false
+}
+
+// HeaderAnomalies returns the anomalies in err if it is a *HeaderError, or nil otherwise.
+func HeaderAnomalies(err error) []Anomaly {
+	var headerErr *HeaderError
+	if errors.As(err, &headerErr) {
+		return headerErr.Anomalies
+	}
+	return nil
+}
+
+func strictAnomalies(inp []byte, header *CartridgeHeader) []Anomaly {
+	var anomalies []Anomaly
+
+	if header.Logo != NintendoLogo {
+		anomalies = append(anomalies, Anomaly{AnomalyLogo, 0x0104, "logo does not match the Nintendo logo"})
+	}
+
+	// Titles are uppercase ASCII, padded with zeros. Once padding starts, everything after it must be zero too.
+	padded := false
+	for x := 0x34; x < 0x3F; x++ {
+		c := inp[x]
+		if c == 0x00 {
+			padded = true
+		} else if padded || c < 0x20 || c > 0x5F {
+			anomalies = append(anomalies, Anomaly{AnomalyTitle, 0x0100 + x, fmt.Sprintf("title contains invalid character 0x%02X", c)})
+			break
+		}
+	}
+
+	if header.OldLicenseeCode == 0x33 && !isLicenseeCode(inp[0x44:0x46]) {
+		anomalies = append(anomalies, Anomaly{AnomalyLicenseeCode, 0x0144,
+			fmt.Sprintf("new licensee code %q is not two ASCII characters", inp[0x44:0x46])})
+	}
+
+	if !header.Type.Known() {
+		anomalies = append(anomalies, Anomaly{AnomalyCartridgeType, 0x0147, fmt.Sprintf("unknown cartridge type 0x%02X", uint8(header.Type))})
+	}
+	if !header.ROMSize.Known() {
+		anomalies = append(anomalies, Anomaly{AnomalyROMSize, 0x0148, fmt.Sprintf("unknown ROM size code 0x%02X", uint8(header.ROMSize))})
+	}
+	if !header.RAMSize.Known() {
+		anomalies = append(anomalies, Anomaly{AnomalyRAMSize, 0x0149, fmt.Sprintf("unknown RAM size code 0x%02X", uint8(header.RAMSize))})
+	}
+
+	return anomalies
+}
+
+func isLicenseeCode(code []byte) bool {
+	for _, c := range code {
+		if !((c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z')) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package gogb
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/anurse/gogb/pkg/gogb/save"
+)
+
+// DefaultFlushInterval is how often a BatterySave writes changed RAM to storage by default.
+const DefaultFlushInterval = 5 * time.Second
+
+// HasBattery returns true if the cartridge keeps its RAM (or clock) alive with a battery, so it
+// should be persisted between sessions.
+func (v CartridgeType) HasBattery() bool {
+	return strings.Contains(v.String(), "Battery")
+}
+
+// A clockCartridge is a Cartridge with a real-time clock, such as an MBC3 with a timer, whose state
+// is saved after the RAM.
+type clockCartridge interface {
+	ExportRTC() (save.RTCFooter, bool)
+	ImportRTC(footer save.RTCFooter)
+}
+
+// ExportRAM returns the cartridge's external RAM in the format of .sav files: a copy of the RAM,
+// followed by an RTC footer if the cartridge has a clock, as other emulators expect.
+func ExportRAM(cart Cartridge) []byte {
+	var footer *save.RTCFooter
+	if c, ok := cart.(clockCartridge); ok {
+		if f, ok := c.ExportRTC(); ok {
+			footer = &f
+		}
+	}
+	return save.Merge(cart.RAM(), footer, save.LayoutEmulator)
+}
+
+// ImportRAM replaces the cartridge's external RAM with the contents of a save file. If the save has an
+// RTC footer and the cartridge has a clock, the clock is restored too. Flashcart padding is ignored.
+// Returns save.ErrSaveTooSmall if the save is smaller than the cartridge RAM.
+func ImportRAM(cart Cartridge, data []byte) error {
+	ram := cart.RAM()
+	sram, footer, err := save.Split(data, len(ram))
+	if err != nil {
+		return err
+	}
+	copy(ram, sram)
+	if c, ok := cart.(clockCartridge); ok && footer != nil {
+		c.ImportRTC(*footer)
+	}
+	return nil
+}
+
+// SaveName returns the name under which to store the saves of the ROM at romPath: the path without its extension.
+func SaveName(romPath string) string {
+	return strings.TrimSuffix(romPath, filepath.Ext(romPath))
+}
+
+// A BatterySave persists the battery-backed RAM and real-time clock of a cartridge in a save.Storage, under save.KindBattery.
+// Frontends call Load on start, Update once per frame (or as often as convenient) and Flush on exit.
+// It is not safe for concurrent use; call it from the goroutine running the emulator.
+//
+// With a save.FileStorage whose Dir is empty and a name from SaveName, the RAM of "games/tetris.gb"
+// is kept in "games/tetris.sav", where other emulators expect it.
+type BatterySave struct {
+	Cartridge Cartridge
+	Storage   save.Storage
+	Name      string
+
+	// How often Update writes changed RAM to storage.
+	Interval time.Duration
+
+	// The exported save as of the last load or flush, to skip writing unchanged data
+	saved     []byte
+	lastFlush time.Time
+}
+
+// NewBatterySave creates a BatterySave that flushes every DefaultFlushInterval.
+// Only use it for cartridge types where HasBattery returns true.
+func NewBatterySave(cart Cartridge, storage save.Storage, name string) *BatterySave {
+	return &BatterySave{Cartridge: cart, Storage: storage, Name: name, Interval: DefaultFlushInterval, lastFlush: time.Now()}
+}
+
+// Load imports the stored RAM (and clock) into the cartridge. If nothing has been stored yet, the RAM is left as is.
+func (b *BatterySave) Load() error {
+	data, err := b.Storage.Load(b.Name, save.KindBattery)
+	if errors.Is(err, save.ErrNotFound) {
+		b.saved = ExportRAM(b.Cartridge)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := ImportRAM(b.Cartridge, data); err != nil {
+		return err
+	}
+	b.saved = ExportRAM(b.Cartridge)
+	return nil
+}
+
+// Flush writes the RAM and clock to storage if they have changed since they were last loaded or flushed.
+func (b *BatterySave) Flush() error {
+	b.lastFlush = time.Now()
+	data := ExportRAM(b.Cartridge)
+	if len(data) == 0 || bytes.Equal(b.saved, data) {
+		return nil
+	}
+	if err := b.Storage.Store(b.Name, save.KindBattery, data); err != nil {
+		return err
+	}
+	b.saved = data
+	return nil
+}
+
+// Update flushes the RAM if Interval has passed since the last flush, so little progress is lost if
+// the frontend crashes.
+func (b *BatterySave) Update() error {
+	if time.Since(b.lastFlush) < b.Interval {
+		return nil
+	}
+	return b.Flush()
+}
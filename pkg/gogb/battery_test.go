@@ -0,0 +1,111 @@
+package gogb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anurse/gogb/pkg/gogb/cartridge"
+	"github.com/anurse/gogb/pkg/gogb/save"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasBattery(t *testing.T) {
+	assert.True(t, CartridgeType(Mbc1RamBattery).HasBattery())
+	assert.True(t, CartridgeType(Mbc3TimerBattery).HasBattery())
+	assert.False(t, CartridgeType(Mbc1Ram).HasBattery())
+	assert.False(t, ROMOnly.HasBattery())
+}
+
+func TestImportRAMAcceptsEmulatorLayout(t *testing.T) {
+	cart := cartridge.NewROM(nil, 4)
+	footer := save.RTCFooter{Timestamp: 1}
+	assert.NoError(t, ImportRAM(cart, append([]byte{1, 2, 3, 4}, footer.Bytes()...)))
+	assert.Equal(t, []byte{1, 2, 3, 4}, ExportRAM(cart), "a cartridge without a clock drops the footer")
+
+	assert.True(t, errors.Is(ImportRAM(cart, []byte{1, 2}), save.ErrSaveTooSmall))
+}
+
+func TestBatterySaveRoundTrip(t *testing.T) {
+	storage := save.NewMemoryStorage()
+	cart := cartridge.NewROM(nil, 4)
+	b := NewBatterySave(cart, storage, "game")
+	assert.NoError(t, b.Load())
+
+	// Unchanged RAM isn't written
+	assert.NoError(t, b.Flush())
+	_, err := storage.Load("game", save.KindBattery)
+	assert.Equal(t, save.ErrNotFound, err)
+
+	cart.SetByte(0xA001, 0x42)
+	assert.NoError(t, b.Flush())
+	data, err := storage.Load("game", save.KindBattery)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0x42, 0, 0}, data)
+
+	restored := cartridge.NewROM(nil, 4)
+	assert.NoError(t, NewBatterySave(restored, storage, "game").Load())
+	assert.Equal(t, []byte{0, 0x42, 0, 0}, restored.RAM())
+}
+
+func TestBatterySaveRoundTripsClock(t *testing.T) {
+	rom := romImage(Mbc3TimerBattery, 0x01, 0x00)
+	now := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := cartridge.ClockFunc(func() time.Time { return now })
+
+	storage := save.NewMemoryStorage()
+	cart := cartridge.NewMBC3(rom, 0, clock)
+	b := NewBatterySave(cart, storage, "game")
+	assert.NoError(t, b.Load())
+
+	// Set the clock to 42 minutes
+	cart.SetByte(0x0000, 0x0A)
+	cart.SetByte(0x4000, 0x09)
+	cart.SetByte(0xA000, 42)
+	assert.NoError(t, b.Flush())
+	data, err := storage.Load("game", save.KindBattery)
+	assert.NoError(t, err)
+	assert.Len(t, data, save.RTCFooterSize)
+
+	// Nothing touched the clock, so the save doesn't change
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Flush())
+	unchanged, _ := storage.Load("game", save.KindBattery)
+	assert.Equal(t, data, unchanged)
+
+	now = now.Add(time.Hour)
+	restored := cartridge.NewMBC3(rom, 0, clock)
+	assert.NoError(t, NewBatterySave(restored, storage, "game").Load())
+	restored.SetByte(0x0000, 0x0A)
+	restored.SetByte(0x6000, 0x00)
+	restored.SetByte(0x6000, 0x01)
+	restored.SetByte(0x4000, 0x09)
+	minutes, _ := restored.GetByte(0xA000)
+	restored.SetByte(0x4000, 0x0A)
+	hours, _ := restored.GetByte(0xA000)
+	assert.Equal(t, uint8(43), minutes)
+	assert.Equal(t, uint8(1), hours)
+}
+
+func TestBatterySaveUpdateFlushesPeriodically(t *testing.T) {
+	storage := save.NewMemoryStorage()
+	cart := cartridge.NewROM(nil, 4)
+	b := NewBatterySave(cart, storage, "game")
+	assert.NoError(t, b.Load())
+	cart.SetByte(0xA000, 0x01)
+
+	assert.NoError(t, b.Update())
+	_, err := storage.Load("game", save.KindBattery)
+	assert.Equal(t, save.ErrNotFound, err)
+
+	b.Interval = 0
+	assert.NoError(t, b.Update())
+	data, err := storage.Load("game", save.KindBattery)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0, 0, 0}, data)
+}
+
+func TestSaveNameUsesROMPathWithoutExtension(t *testing.T) {
+	storage := save.NewFileStorage("")
+	assert.Equal(t, "games/tetris.sav", storage.Path(SaveName("games/tetris.gb"), save.KindBattery))
+}
@@ -0,0 +1,66 @@
+package gogb
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// A BootVerdict reports whether a cartridge would pass the checks performed by the real boot ROMs.
+type BootVerdict struct {
+	// The DMG boot ROM compares the whole logo and the header checksum, and locks up if either fails.
+	BootsOnDMG bool
+
+	// The CGB boot ROM only compares the top half of the logo, plus the header checksum.
+	BootsOnCGB bool
+
+	// Problems that stop the cartridge from booting on at least one model.
+	Problems []string
+
+	// Problems the boot ROMs don't check, but which make it unlikely the game will run, such as an entry point that never jumps to the game.
+	Warnings []string
+}
+
+// Boots returns true if the cartridge would boot on every model.
+func (v BootVerdict) Boots() bool { return v.BootsOnDMG && v.BootsOnCGB }
+
+// CheckBoot determines whether a cartridge with the specified header would boot on real hardware.
+func CheckBoot(header *CartridgeHeader) BootVerdict {
+	topHalf := bytes.Equal(header.Logo[:24], NintendoLogo[:24])
+	checksum := header.HeaderChecksum == header.ComputedHeaderChecksum
+
+	v := BootVerdict{
+		BootsOnDMG: topHalf && header.Logo == NintendoLogo && checksum,
+		BootsOnCGB: topHalf && checksum,
+	}
+
+	if !topHalf {
+		v.Problems = append(v.Problems, "logo does not match (checked by all models)")
+	} else if header.Logo != NintendoLogo {
+		v.Problems = append(v.Problems, "bottom half of logo does not match (checked by DMG only)")
+	}
+	if !checksum {
+		v.Problems = append(v.Problems, fmt.Sprintf("header checksum is 0x%02X but should be 0x%02X", header.HeaderChecksum, header.ComputedHeaderChecksum))
+	}
+	if !entryPointJumps(header.EntryPoint) {
+		v.Warnings = append(v.Warnings, fmt.Sprintf("entry point % X does not jump past the header", header.EntryPoint))
+	}
+	return v
+}
+
+// entryPointJumps checks that the 4-byte entry point reaches a JP or JR before running into the logo,
+// allowing for the NOP and DI instructions games commonly put first.
+func entryPointJumps(entry [4]byte) bool {
+	for pc := 0; pc < len(entry); pc++ {
+		switch entry[pc] {
+		case 0x00, 0xF3: // NOP, DI
+			continue
+		case 0xC3: // JP a16
+			return pc+3 <= len(entry)
+		case 0x18: // JR e8
+			return pc+2 <= len(entry)
+		default:
+			return false
+		}
+	}
+	return false
+}
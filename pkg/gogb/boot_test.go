@@ -0,0 +1,55 @@
+package gogb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bootableHeader() CartridgeHeader {
+	return CartridgeHeader{
+		EntryPoint:             [4]byte{0x00, 0xC3, 0x50, 0x01},
+		Logo:                   NintendoLogo,
+		HeaderChecksum:         0x42,
+		ComputedHeaderChecksum: 0x42,
+	}
+}
+
+func TestCheckBootAcceptsValidHeader(t *testing.T) {
+	header := bootableHeader()
+	v := CheckBoot(&header)
+	assert.True(t, v.Boots())
+	assert.Empty(t, v.Problems)
+	assert.Empty(t, v.Warnings)
+}
+
+func TestCheckBootRejectsBadChecksum(t *testing.T) {
+	header := bootableHeader()
+	header.ComputedHeaderChecksum = 0x43
+	v := CheckBoot(&header)
+	assert.False(t, v.BootsOnDMG)
+	assert.False(t, v.BootsOnCGB)
+	assert.Len(t, v.Problems, 1)
+}
+
+func TestCheckBootAllowsBadBottomHalfOfLogoOnCGB(t *testing.T) {
+	header := bootableHeader()
+	header.Logo[40] = 0x00
+	v := CheckBoot(&header)
+	assert.False(t, v.BootsOnDMG)
+	assert.True(t, v.BootsOnCGB)
+}
+
+func TestCheckBootWarnsAboutEntryPointWithoutJump(t *testing.T) {
+	header := bootableHeader()
+	header.EntryPoint = [4]byte{0x00, 0x00, 0x00, 0xC3}
+	v := CheckBoot(&header)
+	assert.True(t, v.Boots())
+	assert.Len(t, v.Warnings, 1)
+}
+
+func TestCheckBootAcceptsJREntryPoint(t *testing.T) {
+	header := bootableHeader()
+	header.EntryPoint = [4]byte{0xF3, 0x18, 0x4D, 0x00}
+	assert.Empty(t, CheckBoot(&header).Warnings)
+}
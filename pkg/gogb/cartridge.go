@@ -0,0 +1,102 @@
+package gogb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anurse/gogb/pkg/gogb/cartridge"
+	"github.com/anurse/gogb/pkg/gogb/memory"
+)
+
+// ErrROMSizeMismatch indicates that the size of a ROM file doesn't match the ROM size declared in its header.
+var ErrROMSizeMismatch error = errors.New("ROM file size does not match the header")
+
+// ErrRAMSizeInvalid indicates that the RAM size declared in a header is not valid for the cartridge type.
+var ErrRAMSizeInvalid error = errors.New("RAM size is not valid for the cartridge type")
+
+// ErrUnsupportedCartridge indicates that there is no mapper for the cartridge type.
+var ErrUnsupportedCartridge error = errors.New("unsupported cartridge type")
+
+// A Cartridge is the mapper hardware of a game cartridge. It serves the cartridge ROM (0x0000-0x7FFF)
+// and external RAM (0xA000-0xBFFF) using full bus addresses, so it can be mapped onto a memory.Bus as is.
+type Cartridge interface {
+	memory.MMU
+
+	// RAM returns the external RAM (or EEPROM) in the raw layout used by save files. It is empty for
+	// cartridges without RAM. Changes to the returned slice are seen by the cartridge.
+	RAM() []byte
+}
+
+// A mapperInfo describes how to build the mapper for a cartridge type.
+type mapperInfo struct {
+	// Whether the cartridge has external RAM of the size declared in the header
+	hasRAM bool
+
+	// Set for cartridges with a fixed amount of built-in memory, which ignore the declared RAM size
+	builtIn bool
+
+	newMapper func(rom []byte, ramSize int) Cartridge
+}
+
+func newROM(rom []byte, ramSize int) Cartridge  { return cartridge.NewROM(rom, ramSize) }
+func newMBC1(rom []byte, ramSize int) Cartridge { return cartridge.NewMBC1(rom, ramSize) }
+func newMBC3(rom []byte, ramSize int) Cartridge { return cartridge.NewMBC3(rom, ramSize, nil) }
+func newMBC3Timer(rom []byte, ramSize int) Cartridge {
+	return cartridge.NewMBC3(rom, ramSize, cartridge.SystemClock{})
+}
+func newMBC7(rom []byte, ramSize int) Cartridge { return cartridge.NewMBC7(rom, nil) }
+func newHuC1(rom []byte, ramSize int) Cartridge { return cartridge.NewHuC1(rom, ramSize, nil) }
+func newHuC3(rom []byte, ramSize int) Cartridge {
+	return cartridge.NewHuC3(rom, ramSize, cartridge.SystemClock{}, nil)
+}
+
+// mappers lists the supported cartridge types.
+var mappers = map[CartridgeType]mapperInfo{
+	ROMOnly:                    {newMapper: newROM},
+	ROMRAM:                     {hasRAM: true, newMapper: newROM},
+	ROMRAMBattery:              {hasRAM: true, newMapper: newROM},
+	Mbc1:                       {newMapper: newMBC1},
+	Mbc1Ram:                    {hasRAM: true, newMapper: newMBC1},
+	Mbc1RamBattery:             {hasRAM: true, newMapper: newMBC1},
+	Mbc3TimerBattery:           {newMapper: newMBC3Timer},
+	Mbc3TimerRAMBattery:        {hasRAM: true, newMapper: newMBC3Timer},
+	Mbc3:                       {newMapper: newMBC3},
+	Mbc3Ram:                    {hasRAM: true, newMapper: newMBC3},
+	Mbc3RamBattery:             {hasRAM: true, newMapper: newMBC3},
+	Mbc7SensorRumbleRAMBattery: {builtIn: true, newMapper: newMBC7},
+	Huc1RamBattery:             {hasRAM: true, newMapper: newHuC1},
+	Huc3:                       {hasRAM: true, newMapper: newHuC3},
+}
+
+// NewCartridge parses the header of a ROM image and returns the mapper for its cartridge type, with
+// empty external RAM. A header checksum mismatch is ignored, since it only matters to the boot ROM.
+//
+// Returns ErrROMSizeMismatch if the image isn't the size declared in the header, ErrRAMSizeInvalid if
+// the declared RAM size doesn't fit the cartridge type, and ErrUnsupportedCartridge if there is no
+// mapper for the cartridge type.
+func NewCartridge(rom []byte) (Cartridge, error) {
+	if len(rom) < 0x0150 {
+		return nil, fmt.Errorf("%w: 0x%X bytes is too small to contain a header", ErrROMSizeMismatch, len(rom))
+	}
+
+	var header CartridgeHeader
+	if err := ParseHeader(rom[0x0100:0x0150], &header); err != nil && !errors.Is(err, ErrHeaderChecksumInvalid) {
+		return nil, err
+	}
+
+	info, ok := mappers[header.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCartridge, header.Type)
+	}
+	if !header.ROMSize.Known() || header.ROMSize.Bytes() != len(rom) {
+		return nil, fmt.Errorf("%w: header declares %s but the file is 0x%X bytes", ErrROMSizeMismatch, header.ROMSize, len(rom))
+	}
+
+	ramSize := header.RAMSize.Bytes()
+	if !info.builtIn {
+		if !header.RAMSize.Known() || (ramSize > 0) != info.hasRAM {
+			return nil, fmt.Errorf("%w: %s with %s of RAM", ErrRAMSizeInvalid, header.Type, header.RAMSize)
+		}
+	}
+	return info.newMapper(rom, ramSize), nil
+}
@@ -0,0 +1,58 @@
+// Package cartridge implements the memory bank controllers (mappers) found on GameBoy cartridges.
+//
+// Every mapper implements memory.MMU over the cartridge's two windows on the bus, ROM (0x0000-0x7FFF)
+// and external RAM (0xA000-0xBFFF), using full bus addresses so it can be mapped onto a memory.Bus as is.
+package cartridge
+
+import "github.com/anurse/gogb/pkg/gogb/memory"
+
+// Sizes of the switchable banks
+const (
+	ROMBankSize = 0x4000
+	RAMBankSize = 0x2000
+)
+
+// Boundaries of the cartridge's windows on the bus
+const (
+	romEnd   = 0x8000
+	ramStart = 0xA000
+	ramEnd   = 0xC000
+)
+
+// openBus is the value read from disabled or missing memory.
+const openBus = 0xFF
+
+// readBanked reads offset within a bank of data. Banks past the end of data wrap around, as the
+// unconnected high address lines of a smaller chip are ignored. Reading from empty data gives 0xFF.
+func readBanked(data []byte, bankSize int, bank int, offset int) uint8 {
+	if len(data) == 0 {
+		return openBus
+	}
+	return data[(bank*bankSize+offset)%len(data)]
+}
+
+// writeBanked writes offset within a bank of data, wrapping like readBanked. Writes to empty data are ignored.
+func writeBanked(data []byte, bankSize int, bank int, offset int, val uint8) {
+	if len(data) == 0 {
+		return
+	}
+	data[(bank*bankSize+offset)%len(data)] = val
+}
+
+// getWord reads a little-endian word from a mapper as two byte reads, since the bytes may fall in different banks.
+func getWord(m memory.MMU, addr int) (uint16, error) {
+	lo, err := m.GetByte(addr)
+	if err != nil {
+		return 0, err
+	}
+	hi, err := m.GetByte(addr + 1)
+	return uint16(lo) | uint16(hi)<<8, err
+}
+
+// setWord writes a little-endian word to a mapper as two byte writes, since either may hit a mapper register.
+func setWord(m memory.MMU, addr int, val uint16) error {
+	if err := m.SetByte(addr, uint8(val)); err != nil {
+		return err
+	}
+	return m.SetByte(addr+1, uint8(val>>8))
+}
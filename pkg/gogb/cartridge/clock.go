@@ -0,0 +1,21 @@
+package cartridge
+
+import "time"
+
+// A Clock supplies the wall-clock time to cartridges with a real-time clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter that allows an ordinary function to be used as a Clock.
+// It is useful for injecting a fake clock in tests.
+type ClockFunc func() time.Time
+
+// Now calls f().
+func (f ClockFunc) Now() time.Time { return f() }
+
+// SystemClock is a Clock that reads the host's wall clock.
+type SystemClock struct{}
+
+// Now returns time.Now().
+func (SystemClock) Now() time.Time { return time.Now() }
@@ -0,0 +1,91 @@
+package cartridge
+
+import (
+	"github.com/anurse/gogb/pkg/gogb/input"
+	"github.com/anurse/gogb/pkg/gogb/memory"
+)
+
+// A HuC1 is Hudson Soft's MBC1-like mapper with an infrared port. Writing 0x0E to 0x0000-0x1FFF switches
+// 0xA000-0xBFFF from RAM to the IR port, where bit 0 reads the sensor and drives the LED.
+type HuC1 struct {
+	// Infrared is the other end of the IR port. It can be replaced at any time.
+	Infrared input.Infrared
+
+	rom []byte
+	ram []byte
+
+	irMode  bool
+	romBank uint8
+	ramBank uint8
+}
+
+// NewHuC1 creates a HuC1 cartridge with the specified ROM image and external RAM size in bytes.
+// If ir is nil, nothing is connected to the IR port.
+func NewHuC1(rom []byte, ramSize int, ir input.Infrared) *HuC1 {
+	if ir == nil {
+		ir = input.Dark{}
+	}
+	return &HuC1{Infrared: ir, rom: rom, ram: make([]byte, ramSize), romBank: 1}
+}
+
+// RAM returns the external RAM, which may be empty.
+func (m *HuC1) RAM() []byte { return m.ram }
+
+// GetByte reads ROM, external RAM or, in IR mode, the IR sensor: 0xC1 when receiving light and 0xC0 otherwise.
+// Returns memory.ErrAddressOutOfRange for addresses outside the cartridge.
+func (m *HuC1) GetByte(addr int) (uint8, error) {
+	switch {
+	case addr < 0:
+		return 0, memory.ErrAddressOutOfRange
+	case addr < ROMBankSize:
+		return readBanked(m.rom, ROMBankSize, 0, addr), nil
+	case addr < romEnd:
+		return readBanked(m.rom, ROMBankSize, int(m.romBank), addr-ROMBankSize), nil
+	case addr >= ramStart && addr < ramEnd:
+		if m.irMode {
+			if m.Infrared.Light() {
+				return 0xC1, nil
+			}
+			return 0xC0, nil
+		}
+		return readBanked(m.ram, RAMBankSize, int(m.ramBank), addr-ramStart), nil
+	default:
+		return 0, memory.ErrAddressOutOfRange
+	}
+}
+
+// GetWord reads a little-endian word as two byte reads.
+func (m *HuC1) GetWord(addr int) (uint16, error) { return getWord(m, addr) }
+
+// SetByte writes a mapper register (in the ROM area), external RAM or, in IR mode, the IR LED.
+// Returns memory.ErrAddressOutOfRange for addresses outside the cartridge.
+func (m *HuC1) SetByte(addr int, val uint8) error {
+	switch {
+	case addr < 0:
+		return memory.ErrAddressOutOfRange
+	case addr < 0x2000:
+		// Unlike the MBCs there is no RAM gate; any other value selects RAM
+		m.irMode = val&0x0F == 0x0E
+	case addr < 0x4000:
+		m.romBank = val & 0x3F
+		if m.romBank == 0 {
+			m.romBank = 1
+		}
+	case addr < 0x6000:
+		m.ramBank = val & 0x03
+	case addr < romEnd:
+		// Unused
+	case addr >= ramStart && addr < ramEnd:
+		if m.irMode {
+			m.Infrared.SetLED(val&0x01 != 0)
+			return nil
+		}
+		writeBanked(m.ram, RAMBankSize, int(m.ramBank), addr-ramStart, val)
+	default:
+		return memory.ErrAddressOutOfRange
+	}
+	return nil
+}
+
+// SetWord writes a little-endian word as two byte writes.
+func (m *HuC1) SetWord(addr int, val uint16) error { return setWord(m, addr, val) }
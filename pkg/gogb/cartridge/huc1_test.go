@@ -0,0 +1,49 @@
+package cartridge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// loopback is an IR port whose LED shines into its own sensor.
+type loopback struct {
+	on bool
+}
+
+func (l *loopback) SetLED(on bool) { l.on = on }
+
+func (l *loopback) Light() bool { return l.on }
+
+func TestHuC1SelectsBanks(t *testing.T) {
+	m := NewHuC1(bankedROM(64), 0x8000, nil)
+	m.SetByte(0x2000, 0x3F)
+	assert.Equal(t, uint8(0x3F), readByte(t, m, 0x4000))
+
+	m.SetByte(0x4000, 0x02)
+	m.SetByte(0xA000, 0x42)
+	assert.Equal(t, uint8(0x42), m.RAM()[2*RAMBankSize])
+	assert.Equal(t, uint8(0x42), readByte(t, m, 0xA000))
+}
+
+func TestHuC1IRModeWithoutPeripheralSeesNoLight(t *testing.T) {
+	m := NewHuC1(bankedROM(64), 0x2000, nil)
+	m.SetByte(0x0000, 0x0E)
+	assert.Equal(t, uint8(0xC0), readByte(t, m, 0xA000))
+
+	// Writes drive the LED rather than RAM
+	m.SetByte(0xA000, 0x01)
+	assert.Equal(t, uint8(0x00), m.RAM()[0])
+}
+
+func TestHuC1IRModeDrivesPeripheral(t *testing.T) {
+	ir := &loopback{}
+	m := NewHuC1(bankedROM(64), 0x2000, ir)
+	m.SetByte(0x0000, 0x0E)
+	m.SetByte(0xA000, 0x01)
+	assert.True(t, ir.on)
+	assert.Equal(t, uint8(0xC1), readByte(t, m, 0xA000))
+
+	m.SetByte(0x0000, 0x0A)
+	assert.Equal(t, uint8(0x00), readByte(t, m, 0xA000))
+}
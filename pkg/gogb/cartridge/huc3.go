@@ -0,0 +1,218 @@
+package cartridge
+
+import (
+	"time"
+
+	"github.com/anurse/gogb/pkg/gogb/input"
+	"github.com/anurse/gogb/pkg/gogb/memory"
+)
+
+// Values of the HuC3 mode register, which selects what 0xA000-0xBFFF maps
+const (
+	huc3RAM       = 0x0A
+	huc3Command   = 0x0B
+	huc3Response  = 0x0C
+	huc3Semaphore = 0x0D
+	huc3IR        = 0x0E
+)
+
+// HuC3 RTC commands, in bits 4-6 of the command register
+const (
+	huc3Read       = 0x1
+	huc3Write      = 0x3
+	huc3AddrLow    = 0x4
+	huc3AddrHigh   = 0x5
+	huc3Extended   = 0x6
+	huc3ExtGetTime = 0x0
+	huc3ExtSetTime = 0x1
+	huc3ExtStatus  = 0x2
+	huc3ExtTone    = 0xE
+)
+
+// huc3ToneAddr is the RTC memory address holding the tone played by the piezo speaker.
+const huc3ToneAddr = 0x27
+
+// A HuC3 is Hudson Soft's mapper with a real-time clock, a piezo speaker and an infrared port.
+//
+// The clock is a separate chip with 256 nibbles of memory, driven through a semaphore protocol: the program
+// writes a command in mode 0x0B, clears bit 0 of the semaphore in mode 0x0D to run it, waits for the bit to
+// read back as 1, then reads the result in mode 0x0C. The chip counts minutes of the day and days, and
+// copies them to and from memory 0x00-0x06 on request.
+type HuC3 struct {
+	// Infrared is the other end of the IR port. It can be replaced at any time.
+	Infrared input.Infrared
+
+	// OnTone, if set, is called with the tone number whenever the program sounds the piezo speaker.
+	OnTone func(tone uint8)
+
+	rom []byte
+	ram []byte
+
+	mode    uint8
+	romBank uint8
+	ramBank uint8
+
+	clock   Clock
+	last    time.Time
+	minutes int
+	days    uint16
+
+	memory   [256]uint8
+	addr     uint8
+	command  uint8
+	response uint8
+}
+
+// NewHuC3 creates a HuC3 cartridge with the specified ROM image and external RAM size in bytes.
+// The clock drives the real-time clock, which every HuC3 board has, so a nil clock falls back to
+// SystemClock. If ir is nil, nothing is connected to the IR port.
+func NewHuC3(rom []byte, ramSize int, clock Clock, ir input.Infrared) *HuC3 {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	if ir == nil {
+		ir = input.Dark{}
+	}
+	return &HuC3{Infrared: ir, rom: rom, ram: make([]byte, ramSize), romBank: 1, clock: clock, last: clock.Now()}
+}
+
+// RAM returns the external RAM, which may be empty.
+func (m *HuC3) RAM() []byte { return m.ram }
+
+// GetByte reads ROM or whatever the mode register maps at 0xA000-0xBFFF.
+// Returns memory.ErrAddressOutOfRange for addresses outside the cartridge.
+func (m *HuC3) GetByte(addr int) (uint8, error) {
+	switch {
+	case addr < 0:
+		return 0, memory.ErrAddressOutOfRange
+	case addr < ROMBankSize:
+		return readBanked(m.rom, ROMBankSize, 0, addr), nil
+	case addr < romEnd:
+		return readBanked(m.rom, ROMBankSize, int(m.romBank), addr-ROMBankSize), nil
+	case addr >= ramStart && addr < ramEnd:
+		switch m.mode {
+		case huc3RAM:
+			return readBanked(m.ram, RAMBankSize, int(m.ramBank), addr-ramStart), nil
+		case huc3Response:
+			return 0x80 | m.command<<4 | m.response, nil
+		case huc3Semaphore:
+			// Commands complete instantly, so the chip is always ready
+			return 0xFF, nil
+		case huc3IR:
+			if m.Infrared.Light() {
+				return 0xC1, nil
+			}
+			return 0xC0, nil
+		default:
+			return openBus, nil
+		}
+	default:
+		return 0, memory.ErrAddressOutOfRange
+	}
+}
+
+// GetWord reads a little-endian word as two byte reads.
+func (m *HuC3) GetWord(addr int) (uint16, error) { return getWord(m, addr) }
+
+// SetByte writes a mapper register (in the ROM area) or whatever the mode register maps at 0xA000-0xBFFF.
+// Returns memory.ErrAddressOutOfRange for addresses outside the cartridge.
+func (m *HuC3) SetByte(addr int, val uint8) error {
+	switch {
+	case addr < 0:
+		return memory.ErrAddressOutOfRange
+	case addr < 0x2000:
+		m.mode = val & 0x0F
+	case addr < 0x4000:
+		m.romBank = val & 0x7F
+		if m.romBank == 0 {
+			m.romBank = 1
+		}
+	case addr < 0x6000:
+		m.ramBank = val & 0x03
+	case addr < romEnd:
+		// Unused
+	case addr >= ramStart && addr < ramEnd:
+		switch m.mode {
+		case huc3RAM:
+			writeBanked(m.ram, RAMBankSize, int(m.ramBank), addr-ramStart, val)
+		case huc3Command:
+			m.command = (val >> 4) & 0x07
+			m.response = val & 0x0F
+		case huc3Semaphore:
+			if val&0x01 == 0 {
+				m.execute()
+			}
+		case huc3IR:
+			m.Infrared.SetLED(val&0x01 != 0)
+		}
+	default:
+		return memory.ErrAddressOutOfRange
+	}
+	return nil
+}
+
+// SetWord writes a little-endian word as two byte writes.
+func (m *HuC3) SetWord(addr int, val uint16) error { return setWord(m, addr, val) }
+
+// execute runs the pending RTC command. The command's argument is in the low nibble of response, which
+// is replaced by the result.
+func (m *HuC3) execute() {
+	arg := m.response
+	switch m.command {
+	case huc3Read:
+		m.response = m.memory[m.addr] & 0x0F
+		m.addr++
+	case huc3Write:
+		m.memory[m.addr] = arg
+		m.addr++
+	case huc3AddrLow:
+		m.addr = m.addr&0xF0 | arg
+	case huc3AddrHigh:
+		m.addr = m.addr&0x0F | arg<<4
+	case huc3Extended:
+		switch arg {
+		case huc3ExtGetTime:
+			m.update()
+			putNibbles(m.memory[0:3], uint32(m.minutes))
+			putNibbles(m.memory[3:7], uint32(m.days))
+		case huc3ExtSetTime:
+			m.update()
+			m.minutes = int(getNibbles(m.memory[0:3])) % (24 * 60)
+			m.days = uint16(getNibbles(m.memory[3:7]))
+		case huc3ExtStatus:
+			m.response = 0x1
+		case huc3ExtTone:
+			if m.OnTone != nil {
+				m.OnTone(m.memory[huc3ToneAddr] & 0x0F)
+			}
+		}
+	}
+}
+
+// update advances the clock by the whole minutes elapsed on the wall clock since the last update.
+func (m *HuC3) update() {
+	elapsed := int(m.clock.Now().Sub(m.last) / time.Minute)
+	if elapsed <= 0 {
+		return
+	}
+	m.last = m.last.Add(time.Duration(elapsed) * time.Minute)
+	total := m.minutes + elapsed
+	m.minutes = total % (24 * 60)
+	m.days += uint16(total / (24 * 60))
+}
+
+// putNibbles stores val in dst, one nibble per byte, least significant first.
+func putNibbles(dst []uint8, val uint32) {
+	for i := range dst {
+		dst[i] = uint8(val>>(4*uint(i))) & 0x0F
+	}
+}
+
+// getNibbles is the inverse of putNibbles.
+func getNibbles(src []uint8) uint32 {
+	var val uint32
+	for i := range src {
+		val |= uint32(src[i]&0x0F) << (4 * uint(i))
+	}
+	return val
+}
@@ -0,0 +1,98 @@
+package cartridge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createHuC3() (*HuC3, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)}
+	return NewHuC3(bankedROM(128), 0x8000, clock, nil), clock
+}
+
+// rtcCommand runs a command through the semaphore protocol and returns the response nibble.
+func rtcCommand(t *testing.T, m *HuC3, command uint8, arg uint8) uint8 {
+	t.Helper()
+	m.SetByte(0x0000, huc3Command)
+	m.SetByte(0xA000, command<<4|arg)
+	m.SetByte(0x0000, huc3Semaphore)
+	m.SetByte(0xA000, 0xFE)
+	assert.Equal(t, uint8(0x01), readByte(t, m, 0xA000)&0x01, "ready")
+	m.SetByte(0x0000, huc3Response)
+	response := readByte(t, m, 0xA000)
+	assert.Equal(t, 0x80|command<<4, response&0xF0)
+	return response & 0x0F
+}
+
+// readTime copies the clock to RTC memory and reads back the minutes and days.
+func readTime(t *testing.T, m *HuC3) (minutes uint32, days uint32) {
+	t.Helper()
+	rtcCommand(t, m, huc3Extended, huc3ExtGetTime)
+	rtcCommand(t, m, huc3AddrLow, 0x0)
+	rtcCommand(t, m, huc3AddrHigh, 0x0)
+	var nibbles [7]uint8
+	for i := range nibbles {
+		nibbles[i] = rtcCommand(t, m, huc3Read, 0)
+	}
+	return getNibbles(nibbles[0:3]), getNibbles(nibbles[3:7])
+}
+
+func TestHuC3SelectsBanksInRAMMode(t *testing.T) {
+	m, _ := createHuC3()
+	m.SetByte(0x2000, 0x45)
+	assert.Equal(t, uint8(0x45), readByte(t, m, 0x4000))
+
+	m.SetByte(0x4000, 0x01)
+	m.SetByte(0xA000, 0x42)
+	assert.Equal(t, uint8(0x00), m.RAM()[RAMBankSize])
+
+	m.SetByte(0x0000, huc3RAM)
+	m.SetByte(0xA000, 0x42)
+	assert.Equal(t, uint8(0x42), m.RAM()[RAMBankSize])
+}
+
+func TestHuC3DefaultsToSystemClock(t *testing.T) {
+	m := NewHuC3(bankedROM(2), 0, nil, nil)
+	assert.Equal(t, SystemClock{}, m.clock)
+	minutes, days := readTime(t, m)
+	assert.Equal(t, uint32(0), minutes)
+	assert.Equal(t, uint32(0), days)
+}
+
+func TestHuC3ClockCountsMinutesAndDays(t *testing.T) {
+	m, clock := createHuC3()
+	clock.Advance(3*24*time.Hour + 2*time.Hour + 5*time.Minute + 30*time.Second)
+	minutes, days := readTime(t, m)
+	assert.Equal(t, uint32(125), minutes)
+	assert.Equal(t, uint32(3), days)
+}
+
+func TestHuC3SetsClockFromMemory(t *testing.T) {
+	m, clock := createHuC3()
+	rtcCommand(t, m, huc3AddrLow, 0x0)
+	rtcCommand(t, m, huc3AddrHigh, 0x0)
+	for _, nibble := range []uint8{0xF, 0x9, 0x5, 0x1, 0x0, 0x0, 0x0} {
+		rtcCommand(t, m, huc3Write, nibble)
+	}
+	rtcCommand(t, m, huc3Extended, huc3ExtSetTime)
+
+	// 23:59 on day 1, so one minute later is midnight on day 2
+	clock.Advance(time.Minute)
+	minutes, days := readTime(t, m)
+	assert.Equal(t, uint32(0), minutes)
+	assert.Equal(t, uint32(2), days)
+}
+
+func TestHuC3SoundsSpeaker(t *testing.T) {
+	m, _ := createHuC3()
+	var tones []uint8
+	m.OnTone = func(tone uint8) { tones = append(tones, tone) }
+
+	rtcCommand(t, m, huc3AddrLow, 0x7)
+	rtcCommand(t, m, huc3AddrHigh, 0x2)
+	rtcCommand(t, m, huc3Write, 0x3)
+	rtcCommand(t, m, huc3Extended, huc3ExtTone)
+	assert.Equal(t, []uint8{0x3}, tones)
+}
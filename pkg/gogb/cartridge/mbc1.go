@@ -0,0 +1,125 @@
+package cartridge
+
+import (
+	"bytes"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+)
+
+// An MBC1 is the most common mapper, supporting up to 2MB of ROM and 32KB of RAM.
+//
+// It has a 5-bit ROM bank register and a 2-bit secondary register. In mode 0 the secondary register
+// supplies the upper ROM bank bits for 0x4000-0x7FFF only; in mode 1 it also banks 0x0000-0x3FFF
+// and selects the RAM bank.
+type MBC1 struct {
+	// Multicart wires the board as an MBC1M, as used by multi-game compilations: the secondary register
+	// drives ROM bank bits 4-5 instead of 5-6 and bit 4 of the ROM bank register is left unconnected,
+	// so each 256KB game sees its own bank 0 in mode 1. NewMBC1 sets it for detected multicarts;
+	// it can be overridden before the cartridge is used.
+	Multicart bool
+
+	rom []byte
+	ram []byte
+
+	ramEnabled bool
+	bank1      uint8
+	bank2      uint8
+	mode       uint8
+}
+
+// NewMBC1 creates an MBC1 cartridge with the specified ROM image and external RAM size in bytes.
+// Multicart is set if IsMBC1Multicart detects the ROM as a multi-game compilation.
+func NewMBC1(rom []byte, ramSize int) *MBC1 {
+	return &MBC1{Multicart: IsMBC1Multicart(rom), rom: rom, ram: make([]byte, ramSize), bank1: 1}
+}
+
+// IsMBC1Multicart returns true if the ROM looks like an MBC1M multicart. These are 1MB compilations of
+// 256KB games, each with its own header, so the logo at the start of the second game (0x40104) duplicates
+// the one in the main header.
+func IsMBC1Multicart(rom []byte) bool {
+	const gameSize = 0x40000
+	if len(rom) != 4*gameSize {
+		return false
+	}
+	logo := rom[0x0104:0x0134]
+	second := rom[gameSize+0x0104 : gameSize+0x0134]
+	return bytes.Equal(logo, second) && !bytes.Equal(logo, make([]byte, len(logo)))
+}
+
+// RAM returns the external RAM, which may be empty.
+func (m *MBC1) RAM() []byte { return m.ram }
+
+// romBanks returns the ROM banks mapped at 0x0000-0x3FFF and 0x4000-0x7FFF.
+func (m *MBC1) romBanks() (low int, high int) {
+	shift, mask := 5, uint8(0x1F)
+	if m.Multicart {
+		shift, mask = 4, 0x0F
+	}
+	if m.mode == 1 {
+		low = int(m.bank2) << shift
+	}
+	return low, int(m.bank2)<<shift | int(m.bank1&mask)
+}
+
+// ramBank returns the RAM bank mapped at 0xA000-0xBFFF.
+func (m *MBC1) ramBank() int {
+	if m.mode == 1 {
+		return int(m.bank2)
+	}
+	return 0
+}
+
+// GetByte reads ROM or external RAM through the current banks. Disabled RAM reads as 0xFF.
+// Returns memory.ErrAddressOutOfRange for addresses outside the cartridge.
+func (m *MBC1) GetByte(addr int) (uint8, error) {
+	low, high := m.romBanks()
+	switch {
+	case addr < 0:
+		return 0, memory.ErrAddressOutOfRange
+	case addr < ROMBankSize:
+		return readBanked(m.rom, ROMBankSize, low, addr), nil
+	case addr < romEnd:
+		return readBanked(m.rom, ROMBankSize, high, addr-ROMBankSize), nil
+	case addr >= ramStart && addr < ramEnd:
+		if !m.ramEnabled {
+			return openBus, nil
+		}
+		return readBanked(m.ram, RAMBankSize, m.ramBank(), addr-ramStart), nil
+	default:
+		return 0, memory.ErrAddressOutOfRange
+	}
+}
+
+// GetWord reads a little-endian word as two byte reads.
+func (m *MBC1) GetWord(addr int) (uint16, error) { return getWord(m, addr) }
+
+// SetByte writes a mapper register (in the ROM area) or external RAM.
+// Returns memory.ErrAddressOutOfRange for addresses outside the cartridge.
+func (m *MBC1) SetByte(addr int, val uint8) error {
+	switch {
+	case addr < 0:
+		return memory.ErrAddressOutOfRange
+	case addr < 0x2000:
+		m.ramEnabled = val&0x0F == 0x0A
+	case addr < 0x4000:
+		// Bank 0 can't be selected for 0x4000-0x7FFF; the register reads it as 1
+		m.bank1 = val & 0x1F
+		if m.bank1 == 0 {
+			m.bank1 = 1
+		}
+	case addr < 0x6000:
+		m.bank2 = val & 0x03
+	case addr < romEnd:
+		m.mode = val & 0x01
+	case addr >= ramStart && addr < ramEnd:
+		if m.ramEnabled {
+			writeBanked(m.ram, RAMBankSize, m.ramBank(), addr-ramStart, val)
+		}
+	default:
+		return memory.ErrAddressOutOfRange
+	}
+	return nil
+}
+
+// SetWord writes a little-endian word as two byte writes.
+func (m *MBC1) SetWord(addr int, val uint16) error { return setWord(m, addr, val) }
@@ -0,0 +1,126 @@
+package cartridge
+
+import (
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// bankedROM creates a ROM of the specified number of banks, where the first byte of each bank is its number.
+func bankedROM(banks int) []byte {
+	rom := make([]byte, banks*ROMBankSize)
+	for i := 0; i < banks; i++ {
+		rom[i*ROMBankSize] = uint8(i)
+	}
+	return rom
+}
+
+// readByte reads a byte from the cartridge, failing the test on error.
+func readByte(t *testing.T, m memory.MMU, addr int) uint8 {
+	t.Helper()
+	val, err := m.GetByte(addr)
+	assert.NoError(t, err)
+	return val
+}
+
+func TestMBC1SelectsROMBank(t *testing.T) {
+	m := NewMBC1(bankedROM(64), 0)
+	assert.Equal(t, uint8(0), readByte(t, m, 0x0000))
+	assert.Equal(t, uint8(1), readByte(t, m, 0x4000))
+
+	m.SetByte(0x2000, 0x05)
+	assert.Equal(t, uint8(5), readByte(t, m, 0x4000))
+
+	// Bank 0 is translated to bank 1
+	m.SetByte(0x2000, 0x00)
+	assert.Equal(t, uint8(1), readByte(t, m, 0x4000))
+
+	// Only the low 5 bits are used, and the secondary register supplies bits 5-6
+	m.SetByte(0x2000, 0xE3)
+	m.SetByte(0x4000, 0x01)
+	assert.Equal(t, uint8(0x23), readByte(t, m, 0x4000))
+}
+
+func TestMBC1BankZeroTranslationIgnoresSecondaryRegister(t *testing.T) {
+	m := NewMBC1(bankedROM(128), 0)
+	m.SetByte(0x4000, 0x01)
+	m.SetByte(0x2000, 0x20)
+	assert.Equal(t, uint8(0x21), readByte(t, m, 0x4000))
+}
+
+func TestMBC1ModeSelectBanksLowROMAndRAM(t *testing.T) {
+	m := NewMBC1(bankedROM(128), 0x8000)
+	m.SetByte(0x0000, 0x0A)
+	m.SetByte(0x4000, 0x02)
+
+	// Mode 0: the low ROM area and RAM are fixed to bank 0
+	assert.Equal(t, uint8(0), readByte(t, m, 0x0000))
+	m.SetByte(0xA000, 0x11)
+	assert.Equal(t, uint8(0x11), m.RAM()[0])
+
+	m.SetByte(0x6000, 0x01)
+	assert.Equal(t, uint8(0x40), readByte(t, m, 0x0000))
+	m.SetByte(0xA000, 0x22)
+	assert.Equal(t, uint8(0x22), m.RAM()[2*RAMBankSize])
+	assert.Equal(t, uint8(0x11), m.RAM()[0])
+}
+
+func TestMBC1ROMBanksWrapToROMSize(t *testing.T) {
+	m := NewMBC1(bankedROM(4), 0)
+	m.SetByte(0x2000, 0x06)
+	assert.Equal(t, uint8(2), readByte(t, m, 0x4000))
+}
+
+func TestMBC1RAMGate(t *testing.T) {
+	m := NewMBC1(bankedROM(2), 0x2000)
+	m.SetByte(0xA000, 0x42)
+	assert.Equal(t, uint8(0xFF), readByte(t, m, 0xA000))
+	assert.Equal(t, uint8(0x00), m.RAM()[0])
+
+	// Only 0x0A in the low nibble enables RAM
+	m.SetByte(0x0000, 0x1A)
+	m.SetByte(0xA000, 0x42)
+	assert.Equal(t, uint8(0x42), readByte(t, m, 0xA000))
+
+	m.SetByte(0x0000, 0x00)
+	assert.Equal(t, uint8(0xFF), readByte(t, m, 0xA000))
+}
+
+// multicartROM creates a 1MB ROM holding four games, each with a copy of the same logo in its header.
+func multicartROM() []byte {
+	rom := bankedROM(64)
+	for game := 0; game < 4; game++ {
+		for i := 0; i < 0x30; i++ {
+			rom[game*0x40000+0x0104+i] = uint8(0xC0 + i)
+		}
+	}
+	return rom
+}
+
+func TestMBC1DetectsMulticart(t *testing.T) {
+	assert.True(t, IsMBC1Multicart(multicartROM()))
+	assert.False(t, IsMBC1Multicart(bankedROM(64)))
+	assert.False(t, IsMBC1Multicart(bankedROM(32)))
+
+	assert.True(t, NewMBC1(multicartROM(), 0).Multicart)
+}
+
+func TestMBC1MulticartWiresSecondaryRegisterToBit4(t *testing.T) {
+	m := NewMBC1(multicartROM(), 0)
+	m.SetByte(0x4000, 0x02)
+	m.SetByte(0x2000, 0x13)
+	assert.Equal(t, uint8(0x23), readByte(t, m, 0x4000))
+
+	// In mode 1 the low ROM area maps the selected game's bank 0
+	m.SetByte(0x6000, 0x01)
+	assert.Equal(t, uint8(0x20), readByte(t, m, 0x0000))
+}
+
+func TestMBC1MulticartCanBeOverridden(t *testing.T) {
+	m := NewMBC1(bankedROM(64), 0)
+	m.Multicart = true
+	m.SetByte(0x4000, 0x01)
+	m.SetByte(0x2000, 0x02)
+	assert.Equal(t, uint8(0x12), readByte(t, m, 0x4000))
+}
@@ -0,0 +1,227 @@
+package cartridge
+
+import (
+	"time"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+	"github.com/anurse/gogb/pkg/gogb/save"
+)
+
+// Indices of the MBC3 clock registers, which are selected by writing 0x08-0x0C to the RAM bank register.
+const (
+	rtcSeconds = iota
+	rtcMinutes
+	rtcHours
+	rtcDaysLow
+	rtcDaysHigh
+)
+
+// Bits of the high day counter register
+const (
+	rtcDayBit8 = 0x01
+	rtcHalt    = 0x40
+	rtcCarry   = 0x80
+)
+
+// rtcMasks are the bits implemented by each clock register.
+var rtcMasks = [5]uint8{0x3F, 0x3F, 0x1F, 0xFF, 0xC1}
+
+// An MBC3 supports up to 2MB of ROM, 32KB of RAM and, on some boards, a real-time clock.
+//
+// The clock counts seconds, minutes, hours and a 9-bit day counter, which sets a sticky carry bit when
+// it overflows. The program reads a latched copy of the registers, taken by writing 0x00 then 0x01 to
+// 0x6000-0x7FFF.
+type MBC3 struct {
+	rom []byte
+	ram []byte
+	rtc *rtc
+
+	ramEnabled bool
+	romBank    uint8
+	ramBank    uint8
+}
+
+// NewMBC3 creates an MBC3 cartridge with the specified ROM image and external RAM size in bytes.
+// The clock drives the real-time clock; pass nil for boards without one.
+func NewMBC3(rom []byte, ramSize int, clock Clock) *MBC3 {
+	m := &MBC3{rom: rom, ram: make([]byte, ramSize), romBank: 1}
+	if clock != nil {
+		m.rtc = &rtc{clock: clock, last: clock.Now()}
+	}
+	return m
+}
+
+// RAM returns the external RAM, which may be empty.
+func (m *MBC3) RAM() []byte { return m.ram }
+
+// HasRTC returns true if the cartridge has a real-time clock.
+func (m *MBC3) HasRTC() bool { return m.rtc != nil }
+
+// ExportRTC returns the clock in the footer format that emulators append to MBC3 saves.
+// The registers are those as of the footer's timestamp, so the footer only changes when the clock
+// is accessed. Returns false if the cartridge has no clock.
+func (m *MBC3) ExportRTC() (save.RTCFooter, bool) {
+	if m.rtc == nil {
+		return save.RTCFooter{}, false
+	}
+	return save.RTCFooter{Registers: m.rtc.regs, Latched: m.rtc.latched, Timestamp: m.rtc.last.Unix()}, true
+}
+
+// ImportRTC restores the clock from a save footer. The time that passed on the wall clock since the
+// footer's timestamp is added the next time the clock is accessed, unless the clock was halted.
+// Cartridges without a clock ignore the footer.
+func (m *MBC3) ImportRTC(footer save.RTCFooter) {
+	if m.rtc == nil {
+		return
+	}
+	for i, mask := range rtcMasks {
+		m.rtc.regs[i] = footer.Registers[i] & mask
+		m.rtc.latched[i] = footer.Latched[i] & mask
+	}
+
+	// A timestamp from the future (such as a save copied from a machine with a skewed clock) would
+	// stop the clock until the wall clock caught up
+	m.rtc.last = time.Unix(footer.Timestamp, 0)
+	if now := m.rtc.clock.Now(); m.rtc.last.After(now) {
+		m.rtc.last = now
+	}
+}
+
+// GetByte reads ROM, external RAM or the selected latched clock register.
+// Disabled RAM and clock registers read as 0xFF.
+// Returns memory.ErrAddressOutOfRange for addresses outside the cartridge.
+func (m *MBC3) GetByte(addr int) (uint8, error) {
+	switch {
+	case addr < 0:
+		return 0, memory.ErrAddressOutOfRange
+	case addr < ROMBankSize:
+		return readBanked(m.rom, ROMBankSize, 0, addr), nil
+	case addr < romEnd:
+		return readBanked(m.rom, ROMBankSize, int(m.romBank), addr-ROMBankSize), nil
+	case addr >= ramStart && addr < ramEnd:
+		if !m.ramEnabled {
+			return openBus, nil
+		}
+		if m.ramBank >= 0x08 {
+			if m.rtc == nil || m.ramBank > 0x0C {
+				return openBus, nil
+			}
+			return m.rtc.latched[m.ramBank-0x08], nil
+		}
+		return readBanked(m.ram, RAMBankSize, int(m.ramBank), addr-ramStart), nil
+	default:
+		return 0, memory.ErrAddressOutOfRange
+	}
+}
+
+// GetWord reads a little-endian word as two byte reads.
+func (m *MBC3) GetWord(addr int) (uint16, error) { return getWord(m, addr) }
+
+// SetByte writes a mapper register (in the ROM area), external RAM or the selected clock register.
+// Returns memory.ErrAddressOutOfRange for addresses outside the cartridge.
+func (m *MBC3) SetByte(addr int, val uint8) error {
+	switch {
+	case addr < 0:
+		return memory.ErrAddressOutOfRange
+	case addr < 0x2000:
+		m.ramEnabled = val&0x0F == 0x0A
+	case addr < 0x4000:
+		m.romBank = val & 0x7F
+		if m.romBank == 0 {
+			m.romBank = 1
+		}
+	case addr < 0x6000:
+		m.ramBank = val & 0x0F
+	case addr < romEnd:
+		if m.rtc != nil {
+			m.rtc.writeLatch(val)
+		}
+	case addr >= ramStart && addr < ramEnd:
+		if !m.ramEnabled {
+			return nil
+		}
+		if m.ramBank >= 0x08 {
+			if m.rtc != nil && m.ramBank <= 0x0C {
+				m.rtc.write(int(m.ramBank-0x08), val)
+			}
+			return nil
+		}
+		writeBanked(m.ram, RAMBankSize, int(m.ramBank), addr-ramStart, val)
+	default:
+		return memory.ErrAddressOutOfRange
+	}
+	return nil
+}
+
+// SetWord writes a little-endian word as two byte writes.
+func (m *MBC3) SetWord(addr int, val uint16) error { return setWord(m, addr, val) }
+
+// rtc is the MBC3 real-time clock. Rather than ticking, it catches up with the wall clock whenever
+// it is accessed.
+type rtc struct {
+	clock Clock
+
+	regs    [5]uint8
+	latched [5]uint8
+
+	// The wall-clock time up to which regs have been advanced.
+	last time.Time
+
+	// The last value written to the latch register; latching happens on a 0x00 to 0x01 transition.
+	latch uint8
+}
+
+// update advances the registers by the whole seconds elapsed on the wall clock since the last update.
+// While halted, the clock doesn't advance. If the wall clock goes backwards, the registers are left alone.
+func (r *rtc) update() {
+	now := r.clock.Now()
+	if r.regs[rtcDaysHigh]&rtcHalt != 0 {
+		r.last = now
+		return
+	}
+	elapsed := int64(now.Sub(r.last) / time.Second)
+	if elapsed <= 0 {
+		return
+	}
+	r.last = r.last.Add(time.Duration(elapsed) * time.Second)
+	r.advance(elapsed)
+}
+
+// advance adds seconds to the registers, carrying into minutes, hours and days.
+func (r *rtc) advance(seconds int64) {
+	total := int64(r.regs[rtcSeconds]) + 60*int64(r.regs[rtcMinutes]) + 3600*int64(r.regs[rtcHours]) + seconds
+	days := int64(r.regs[rtcDaysLow]) | int64(r.regs[rtcDaysHigh]&rtcDayBit8)<<8
+	days += total / 86400
+	total %= 86400
+
+	r.regs[rtcSeconds] = uint8(total % 60)
+	r.regs[rtcMinutes] = uint8(total / 60 % 60)
+	r.regs[rtcHours] = uint8(total / 3600)
+
+	// The day counter is 9 bits wide; overflowing it sets the carry bit, which stays set until cleared
+	if days > 0x1FF {
+		r.regs[rtcDaysHigh] |= rtcCarry
+		days &= 0x1FF
+	}
+	r.regs[rtcDaysLow] = uint8(days)
+	r.regs[rtcDaysHigh] = r.regs[rtcDaysHigh]&^rtcDayBit8 | uint8(days>>8)
+}
+
+// write sets a clock register. Writing the seconds also restarts the current second.
+func (r *rtc) write(reg int, val uint8) {
+	r.update()
+	r.regs[reg] = val & rtcMasks[reg]
+	if reg == rtcSeconds {
+		r.last = r.clock.Now()
+	}
+}
+
+// writeLatch handles a write to the latch register, copying the registers into the latched set
+// when 0x01 follows 0x00.
+func (r *rtc) writeLatch(val uint8) {
+	if r.latch == 0x00 && val == 0x01 {
+		r.update()
+		r.latched = r.regs
+	}
+	r.latch = val
+}
@@ -0,0 +1,156 @@
+package cartridge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anurse/gogb/pkg/gogb/save"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a Clock that only moves when the test advances it.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func createRTCCartridge() (*MBC3, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := NewMBC3(bankedROM(128), 0x8000, clock)
+	m.SetByte(0x0000, 0x0A)
+	return m, clock
+}
+
+// latch latches the clock and returns the S, M, H, DL and DH registers.
+func latch(t *testing.T, m *MBC3) [5]uint8 {
+	t.Helper()
+	m.SetByte(0x6000, 0x00)
+	m.SetByte(0x6000, 0x01)
+	var regs [5]uint8
+	for i := range regs {
+		m.SetByte(0x4000, uint8(0x08+i))
+		regs[i] = readByte(t, m, 0xA000)
+	}
+	return regs
+}
+
+func TestMBC3SelectsROMAndRAMBanks(t *testing.T) {
+	m := NewMBC3(bankedROM(128), 0x8000, nil)
+	m.SetByte(0x2000, 0x7F)
+	assert.Equal(t, uint8(0x7F), readByte(t, m, 0x4000))
+	m.SetByte(0x2000, 0x00)
+	assert.Equal(t, uint8(0x01), readByte(t, m, 0x4000))
+
+	m.SetByte(0x0000, 0x0A)
+	m.SetByte(0x4000, 0x03)
+	m.SetByte(0xA001, 0x42)
+	assert.Equal(t, uint8(0x42), m.RAM()[3*RAMBankSize+1])
+	assert.False(t, m.HasRTC())
+}
+
+func TestMBC3ClockCountsWallClockTime(t *testing.T) {
+	m, clock := createRTCCartridge()
+	clock.Advance(2*24*time.Hour + 3*time.Hour + 4*time.Minute + 5*time.Second + 500*time.Millisecond)
+	assert.Equal(t, [5]uint8{5, 4, 3, 2, 0}, latch(t, m))
+
+	// The half second isn't lost
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, [5]uint8{6, 4, 3, 2, 0}, latch(t, m))
+}
+
+func TestMBC3ReadsLatchedRegisters(t *testing.T) {
+	m, clock := createRTCCartridge()
+	clock.Advance(10 * time.Second)
+	latch(t, m)
+
+	clock.Advance(10 * time.Second)
+	m.SetByte(0x4000, 0x08)
+	assert.Equal(t, uint8(10), readByte(t, m, 0xA000))
+
+	// Writing 0x01 again without 0x00 first doesn't latch
+	m.SetByte(0x6000, 0x01)
+	assert.Equal(t, uint8(10), readByte(t, m, 0xA000))
+}
+
+func TestMBC3HaltStopsClock(t *testing.T) {
+	m, clock := createRTCCartridge()
+	m.SetByte(0x4000, 0x0C)
+	m.SetByte(0xA000, rtcHalt)
+	clock.Advance(time.Hour)
+	assert.Equal(t, [5]uint8{0, 0, 0, 0, rtcHalt}, latch(t, m))
+
+	m.SetByte(0x4000, 0x0C)
+	m.SetByte(0xA000, 0x00)
+	clock.Advance(time.Minute)
+	assert.Equal(t, [5]uint8{0, 1, 0, 0, 0}, latch(t, m))
+}
+
+func TestMBC3DayCounterCarries(t *testing.T) {
+	m, clock := createRTCCartridge()
+	clock.Advance(255 * 24 * time.Hour)
+	assert.Equal(t, [5]uint8{0, 0, 0, 255, 0}, latch(t, m))
+
+	clock.Advance(24 * time.Hour)
+	assert.Equal(t, [5]uint8{0, 0, 0, 0, rtcDayBit8}, latch(t, m))
+
+	clock.Advance(256 * 24 * time.Hour)
+	assert.Equal(t, [5]uint8{0, 0, 0, 0, rtcCarry}, latch(t, m))
+
+	// The carry stays set until the program clears it
+	clock.Advance(24 * time.Hour)
+	assert.Equal(t, [5]uint8{0, 0, 0, 1, rtcCarry}, latch(t, m))
+	m.SetByte(0x4000, 0x0C)
+	m.SetByte(0xA000, 0x00)
+	assert.Equal(t, [5]uint8{0, 0, 0, 1, 0}, latch(t, m))
+}
+
+func TestMBC3WritesClockRegisters(t *testing.T) {
+	m, clock := createRTCCartridge()
+	m.SetByte(0x4000, 0x0A)
+	m.SetByte(0xA000, 23)
+	m.SetByte(0x4000, 0x09)
+	m.SetByte(0xA000, 59)
+	m.SetByte(0x4000, 0x08)
+	m.SetByte(0xA000, 59)
+
+	clock.Advance(time.Second)
+	assert.Equal(t, [5]uint8{0, 0, 0, 1, 0}, latch(t, m))
+}
+
+func TestMBC3ExportsAndImportsRTC(t *testing.T) {
+	m, clock := createRTCCartridge()
+	clock.Advance(3*time.Hour + 20*time.Second)
+	regs := latch(t, m)
+
+	footer, ok := m.ExportRTC()
+	assert.True(t, ok)
+	assert.Equal(t, regs, footer.Registers)
+	assert.Equal(t, regs, footer.Latched)
+	assert.Equal(t, clock.Now().Unix(), footer.Timestamp)
+
+	// The time between saving and loading is added to the clock
+	clock.Advance(24 * time.Hour)
+	restored := NewMBC3(bankedROM(128), 0x8000, clock)
+	restored.SetByte(0x0000, 0x0A)
+	restored.ImportRTC(footer)
+	restored.SetByte(0x4000, 0x0A)
+	assert.Equal(t, uint8(3), readByte(t, restored, 0xA000), "latched hours are restored")
+	assert.Equal(t, [5]uint8{20, 0, 3, 1, 0}, latch(t, restored))
+}
+
+func TestMBC3ImportRTCClampsFutureTimestamp(t *testing.T) {
+	m, clock := createRTCCartridge()
+	m.ImportRTC(save.RTCFooter{Timestamp: clock.Now().Add(time.Hour).Unix()})
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, [5]uint8{5, 0, 0, 0, 0}, latch(t, m))
+}
+
+func TestMBC3WithoutClockHasNoRTCToExport(t *testing.T) {
+	m := NewMBC3(bankedROM(2), 0, nil)
+	_, ok := m.ExportRTC()
+	assert.False(t, ok)
+	m.ImportRTC(save.RTCFooter{Registers: [5]uint8{1, 2, 3, 4, 0}})
+}
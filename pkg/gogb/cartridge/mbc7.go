@@ -0,0 +1,297 @@
+package cartridge
+
+import (
+	"github.com/anurse/gogb/pkg/gogb/input"
+	"github.com/anurse/gogb/pkg/gogb/memory"
+)
+
+// EEPROMSize is the size in bytes of the 93LC56 EEPROM on MBC7 cartridges.
+const EEPROMSize = 256
+
+// An MBC7 is the mapper used by Kirby Tilt 'n' Tumble and Command Master. Instead of RAM it has a two-axis
+// accelerometer and a 93LC56 serial EEPROM, both accessed through registers at 0xA000-0xAFFF once two
+// separate enable registers are set. Bits 4-7 of the address select the register.
+type MBC7 struct {
+	// Accelerometer supplies the tilt read by the sensor. It can be replaced at any time.
+	Accelerometer input.Accelerometer
+
+	rom    []byte
+	eeprom eeprom
+
+	romBank     uint8
+	ramEnabled1 bool
+	ramEnabled2 bool
+
+	// The latched sensor values, and whether they have been erased so a new latch can happen
+	accelX, accelY uint16
+	erased         bool
+}
+
+// NewMBC7 creates an MBC7 cartridge with the specified ROM image. If accel is nil, the cartridge lies level.
+func NewMBC7(rom []byte, accel input.Accelerometer) *MBC7 {
+	if accel == nil {
+		accel = input.Level{}
+	}
+	return &MBC7{
+		Accelerometer: accel,
+		rom:           rom,
+		eeprom:        newEEPROM(),
+		romBank:       1,
+		accelX:        0x8000,
+		accelY:        0x8000,
+	}
+}
+
+// RAM returns the EEPROM contents. Each 16-bit EEPROM word is stored little-endian.
+func (m *MBC7) RAM() []byte { return m.eeprom.data[:] }
+
+// GetByte reads ROM or one of the sensor and EEPROM registers.
+// Returns memory.ErrAddressOutOfRange for addresses outside the cartridge.
+func (m *MBC7) GetByte(addr int) (uint8, error) {
+	switch {
+	case addr < 0:
+		return 0, memory.ErrAddressOutOfRange
+	case addr < ROMBankSize:
+		return readBanked(m.rom, ROMBankSize, 0, addr), nil
+	case addr < romEnd:
+		return readBanked(m.rom, ROMBankSize, int(m.romBank), addr-ROMBankSize), nil
+	case addr >= ramStart && addr < ramEnd:
+		if !m.ramEnabled1 || !m.ramEnabled2 || addr >= 0xB000 {
+			return openBus, nil
+		}
+		switch (addr >> 4) & 0x0F {
+		case 0x2:
+			return uint8(m.accelX), nil
+		case 0x3:
+			return uint8(m.accelX >> 8), nil
+		case 0x4:
+			return uint8(m.accelY), nil
+		case 0x5:
+			return uint8(m.accelY >> 8), nil
+		case 0x6:
+			return 0x00, nil
+		case 0x8:
+			return m.eeprom.read(), nil
+		default:
+			return openBus, nil
+		}
+	default:
+		return 0, memory.ErrAddressOutOfRange
+	}
+}
+
+// GetWord reads a little-endian word as two byte reads.
+func (m *MBC7) GetWord(addr int) (uint16, error) { return getWord(m, addr) }
+
+// SetByte writes a mapper register, either in the ROM area or at 0xA000-0xAFFF.
+// Returns memory.ErrAddressOutOfRange for addresses outside the cartridge.
+func (m *MBC7) SetByte(addr int, val uint8) error {
+	switch {
+	case addr < 0:
+		return memory.ErrAddressOutOfRange
+	case addr < 0x2000:
+		m.ramEnabled1 = val == 0x0A
+	case addr < 0x4000:
+		m.romBank = val & 0x7F
+	case addr < 0x6000:
+		m.ramEnabled2 = val == 0x40
+	case addr < romEnd:
+		// Unused
+	case addr >= ramStart && addr < ramEnd:
+		if !m.ramEnabled1 || !m.ramEnabled2 || addr >= 0xB000 {
+			return nil
+		}
+		switch (addr >> 4) & 0x0F {
+		case 0x0:
+			// Erasing resets the latched values to 0x8000 and arms the latch
+			if val == 0x55 {
+				m.accelX, m.accelY = 0x8000, 0x8000
+				m.erased = true
+			}
+		case 0x1:
+			if val == 0xAA && m.erased {
+				x, y := m.Accelerometer.Tilt()
+				m.accelX, m.accelY = input.SensorValue(x), input.SensorValue(y)
+				m.erased = false
+			}
+		case 0x8:
+			m.eeprom.write(val)
+		}
+	default:
+		return memory.ErrAddressOutOfRange
+	}
+	return nil
+}
+
+// SetWord writes a little-endian word as two byte writes.
+func (m *MBC7) SetWord(addr int, val uint16) error { return setWord(m, addr, val) }
+
+// Bits of the MBC7 EEPROM register
+const (
+	eepromCS  = 0x80
+	eepromCLK = 0x40
+	eepromDI  = 0x02
+	eepromDO  = 0x01
+)
+
+// The states of the EEPROM's serial protocol
+const (
+	// Waiting for a start bit
+	eepromIdle = iota
+	// Shifting in the 2-bit opcode and 8-bit address
+	eepromCommand
+	// Shifting out data
+	eepromReading
+	// Shifting in 16 bits of data for WRITE or WRAL
+	eepromWriting
+	// Finished a command; waiting for CS to drop
+	eepromDone
+)
+
+// eeprom emulates a 93LC56 in 16-bit mode: 128 words, accessed through a serial protocol in which the
+// program drives chip select (CS), the clock (CLK) and data in (DI), and reads data out (DO). Bits are
+// transferred on the rising edge of CLK, most significant first. Each command is a start bit, a 2-bit
+// opcode and an 8-bit address; writes only take effect after an EWEN command.
+type eeprom struct {
+	data [EEPROMSize]byte
+
+	// The pin levels last written by the program, and the level of DO
+	pins uint8
+	do   bool
+
+	state        int
+	shift        uint16
+	bits         int
+	addr         uint8
+	writeAll     bool
+	writeEnabled bool
+}
+
+func newEEPROM() eeprom {
+	e := eeprom{do: true}
+	for i := range e.data {
+		e.data[i] = 0xFF
+	}
+	return e
+}
+
+func (e *eeprom) word(addr uint8) uint16 {
+	return uint16(e.data[addr*2]) | uint16(e.data[addr*2+1])<<8
+}
+
+func (e *eeprom) setWord(addr uint8, val uint16) {
+	e.data[addr*2] = uint8(val)
+	e.data[addr*2+1] = uint8(val >> 8)
+}
+
+// read returns the pins as last written, with DO in bit 0.
+func (e *eeprom) read() uint8 {
+	val := e.pins &^ eepromDO
+	if e.do {
+		val |= eepromDO
+	}
+	return val
+}
+
+// write sets the pins. Dropping CS abandons any command in progress; a rising CLK with CS high clocks in DI.
+func (e *eeprom) write(val uint8) {
+	rising := e.pins&eepromCLK == 0 && val&eepromCLK != 0
+	e.pins = val & (eepromCS | eepromCLK | eepromDI)
+
+	if val&eepromCS == 0 {
+		e.state = eepromIdle
+		return
+	}
+	if rising {
+		e.clock(val&eepromDI != 0)
+	}
+}
+
+// clock handles one rising edge of CLK with the given DI level.
+func (e *eeprom) clock(di bool) {
+	bit := uint16(0)
+	if di {
+		bit = 1
+	}
+
+	switch e.state {
+	case eepromIdle:
+		if di {
+			e.state = eepromCommand
+			e.shift, e.bits = 0, 0
+		}
+	case eepromCommand:
+		e.shift = e.shift<<1 | bit
+		e.bits++
+		if e.bits == 10 {
+			e.execute()
+		}
+	case eepromReading:
+		// Sequential reads continue into the following words
+		e.do = e.shift&0x8000 != 0
+		e.shift <<= 1
+		e.bits++
+		if e.bits == 16 {
+			e.addr = (e.addr + 1) & 0x7F
+			e.shift, e.bits = e.word(e.addr), 0
+		}
+	case eepromWriting:
+		e.shift = e.shift<<1 | bit
+		e.bits++
+		if e.bits == 16 {
+			if e.writeEnabled {
+				if e.writeAll {
+					for addr := uint8(0); addr < EEPROMSize/2; addr++ {
+						e.setWord(addr, e.shift)
+					}
+				} else {
+					e.setWord(e.addr, e.shift)
+				}
+			}
+			// Writes complete instantly, so DO reports ready straight away
+			e.do = true
+			e.state = eepromDone
+		}
+	}
+}
+
+// execute runs the command shifted in, made of a 2-bit opcode and an 8-bit address.
+func (e *eeprom) execute() {
+	opcode, address := e.shift>>8, uint8(e.shift)
+	e.addr = address & 0x7F
+	e.shift, e.bits = 0, 0
+	e.state = eepromDone
+
+	switch opcode {
+	case 0x2: // READ, which outputs a dummy 0 bit before the data
+		e.shift = e.word(e.addr)
+		e.do = false
+		e.state = eepromReading
+	case 0x1: // WRITE
+		e.writeAll = false
+		e.state = eepromWriting
+	case 0x3: // ERASE
+		if e.writeEnabled {
+			e.setWord(e.addr, 0xFFFF)
+		}
+		e.do = true
+	case 0x0:
+		// The top two address bits select the command
+		switch address >> 6 {
+		case 0x0: // EWDS
+			e.writeEnabled = false
+		case 0x1: // WRAL
+			e.writeAll = true
+			e.state = eepromWriting
+		case 0x2: // ERAL
+			if e.writeEnabled {
+				for i := range e.data {
+					e.data[i] = 0xFF
+				}
+			}
+			e.do = true
+		case 0x3: // EWEN
+			e.writeEnabled = true
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package cartridge
+
+import (
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/input"
+	"github.com/stretchr/testify/assert"
+)
+
+func createMBC7(accel input.Accelerometer) *MBC7 {
+	m := NewMBC7(bankedROM(8), accel)
+	m.SetByte(0x0000, 0x0A)
+	m.SetByte(0x4000, 0x40)
+	return m
+}
+
+// clockBits sends bits to the EEPROM, most significant first, and returns the DO level after each one.
+func clockBits(m *MBC7, val uint32, count int) uint32 {
+	var out uint32
+	for i := count - 1; i >= 0; i-- {
+		di := uint8(0)
+		if val&(1<<uint(i)) != 0 {
+			di = eepromDI
+		}
+		m.SetByte(0xA080, eepromCS|di)
+		m.SetByte(0xA080, eepromCS|eepromCLK|di)
+		do, _ := m.GetByte(0xA080)
+		out = out<<1 | uint32(do&eepromDO)
+	}
+	return out
+}
+
+// command selects the EEPROM and sends a start bit, opcode and address.
+func command(m *MBC7, opcode uint32, addr uint32) {
+	m.SetByte(0xA080, 0x00)
+	clockBits(m, 1<<10|opcode<<8|addr, 11)
+}
+
+func TestMBC7RegistersNeedBothEnables(t *testing.T) {
+	m := NewMBC7(bankedROM(8), nil)
+	m.SetByte(0x0000, 0x0A)
+	assert.Equal(t, uint8(0xFF), readByte(t, m, 0xA020))
+	m.SetByte(0x4000, 0x40)
+	assert.Equal(t, uint8(0x00), readByte(t, m, 0xA020))
+	assert.Equal(t, uint8(0x80), readByte(t, m, 0xA030))
+}
+
+func TestMBC7LatchesAccelerometer(t *testing.T) {
+	x, y := 1.0, -0.5
+	m := createMBC7(input.AccelFunc(func() (float64, float64) { return x, y }))
+
+	// Latching only works after erasing
+	m.SetByte(0xA010, 0xAA)
+	assert.Equal(t, uint8(0x80), readByte(t, m, 0xA030))
+
+	m.SetByte(0xA000, 0x55)
+	m.SetByte(0xA010, 0xAA)
+	expectedX, expectedY := input.SensorValue(x), input.SensorValue(y)
+	assert.Equal(t, uint8(expectedX), readByte(t, m, 0xA020))
+	assert.Equal(t, uint8(expectedX>>8), readByte(t, m, 0xA030))
+	assert.Equal(t, uint8(expectedY), readByte(t, m, 0xA040))
+	assert.Equal(t, uint8(expectedY>>8), readByte(t, m, 0xA050))
+
+	// The latch holds until erased again
+	x = 0
+	m.SetByte(0xA010, 0xAA)
+	assert.Equal(t, uint8(expectedX), readByte(t, m, 0xA020))
+}
+
+func TestMBC7EEPROMWriteNeedsWriteEnable(t *testing.T) {
+	m := createMBC7(nil)
+	command(m, 0x1, 0x05)
+	clockBits(m, 0x1234, 16)
+	assert.Equal(t, uint8(0xFF), m.RAM()[10])
+
+	command(m, 0x0, 0xC0)
+	command(m, 0x1, 0x05)
+	clockBits(m, 0x1234, 16)
+	assert.Equal(t, []byte{0x34, 0x12}, m.RAM()[10:12])
+	assert.Equal(t, uint8(eepromCS|eepromCLK|eepromDO), readByte(t, m, 0xA080))
+}
+
+func TestMBC7EEPROMReadsSequentially(t *testing.T) {
+	m := createMBC7(nil)
+	copy(m.RAM(), []byte{0xCD, 0xAB, 0x34, 0x12})
+
+	command(m, 0x2, 0x00)
+	assert.Equal(t, uint8(0), readByte(t, m, 0xA080)&eepromDO, "dummy bit")
+	assert.Equal(t, uint32(0xABCD1234), clockBits(m, 0, 32))
+}
+
+func TestMBC7EEPROMEraseAll(t *testing.T) {
+	m := createMBC7(nil)
+	copy(m.RAM(), []byte{0x00, 0x00})
+	command(m, 0x0, 0xC0)
+	command(m, 0x0, 0x80)
+	assert.Equal(t, []byte{0xFF, 0xFF}, m.RAM()[0:2])
+
+	command(m, 0x0, 0x00)
+	command(m, 0x0, 0x40)
+	clockBits(m, 0x0000, 16)
+	assert.Equal(t, []byte{0xFF, 0xFF}, m.RAM()[0:2])
+}
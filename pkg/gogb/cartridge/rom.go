@@ -0,0 +1,54 @@
+package cartridge
+
+import "github.com/anurse/gogb/pkg/gogb/memory"
+
+// A ROM is a cartridge without a mapper: 32KB of ROM and, optionally, up to 8KB of RAM, all fixed in place.
+type ROM struct {
+	rom []byte
+	ram []byte
+}
+
+// NewROM creates a cartridge without a mapper with the specified ROM image and external RAM size in bytes.
+func NewROM(rom []byte, ramSize int) *ROM {
+	return &ROM{rom: rom, ram: make([]byte, ramSize)}
+}
+
+// RAM returns the external RAM, which may be empty.
+func (m *ROM) RAM() []byte { return m.ram }
+
+// GetByte reads ROM or external RAM. Missing RAM reads as 0xFF.
+// Returns memory.ErrAddressOutOfRange for addresses outside the cartridge.
+func (m *ROM) GetByte(addr int) (uint8, error) {
+	switch {
+	case addr < 0:
+		return 0, memory.ErrAddressOutOfRange
+	case addr < romEnd:
+		return readBanked(m.rom, ROMBankSize, 0, addr), nil
+	case addr >= ramStart && addr < ramEnd:
+		return readBanked(m.ram, RAMBankSize, 0, addr-ramStart), nil
+	default:
+		return 0, memory.ErrAddressOutOfRange
+	}
+}
+
+// GetWord reads a little-endian word as two byte reads.
+func (m *ROM) GetWord(addr int) (uint16, error) { return getWord(m, addr) }
+
+// SetByte writes external RAM. Writes to ROM are ignored.
+// Returns memory.ErrAddressOutOfRange for addresses outside the cartridge.
+func (m *ROM) SetByte(addr int, val uint8) error {
+	switch {
+	case addr < 0:
+		return memory.ErrAddressOutOfRange
+	case addr < romEnd:
+		// Nothing to write to
+	case addr >= ramStart && addr < ramEnd:
+		writeBanked(m.ram, RAMBankSize, 0, addr-ramStart, val)
+	default:
+		return memory.ErrAddressOutOfRange
+	}
+	return nil
+}
+
+// SetWord writes a little-endian word as two byte writes.
+func (m *ROM) SetWord(addr int, val uint16) error { return setWord(m, addr, val) }
@@ -0,0 +1,99 @@
+package gogb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/cartridge"
+	"github.com/stretchr/testify/assert"
+)
+
+// romImage creates a ROM image with the specified header fields and a size matching the ROM size code.
+func romImage(typ CartridgeType, romSize ROMSize, ramSize RAMSize) []byte {
+	rom := make([]byte, romSize.Bytes())
+	copy(rom[0x0100:0x0150], validHeader())
+	rom[0x0147] = byte(typ)
+	rom[0x0148] = byte(romSize)
+	rom[0x0149] = byte(ramSize)
+	fixChecksum(rom[0x0100:0x0150])
+	return rom
+}
+
+func FuzzNewCartridge(f *testing.F) {
+	f.Add(romImage(ROMOnly, 0x00, 0x00))
+	f.Add(romImage(Mbc1RamBattery, 0x01, 0x03))
+	f.Add(romImage(Mbc3TimerRAMBattery, 0x01, 0x03))
+	f.Add(romImage(Mbc7SensorRumbleRAMBattery, 0x01, 0x00))
+	f.Add(romImage(Huc1RamBattery, 0x01, 0x02))
+	f.Add(make([]byte, 0x0150))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, rom []byte) {
+		c, err := NewCartridge(rom)
+		if err != nil {
+			return
+		}
+
+		// Poke every page of the cartridge's address ranges with bytes from the ROM, so mapper
+		// registers are driven with arbitrary values too
+		for addr := 0; addr < 0x10000; addr += 0x100 {
+			c.SetByte(addr, rom[addr%len(rom)])
+			c.GetByte(addr)
+		}
+		_ = c.RAM()
+	})
+}
+
+func TestNewCartridgeBuildsMapperForType(t *testing.T) {
+	cases := []struct {
+		typ      CartridgeType
+		ramSize  RAMSize
+		expected Cartridge
+	}{
+		{ROMOnly, 0x00, &cartridge.ROM{}},
+		{Mbc1RamBattery, 0x03, &cartridge.MBC1{}},
+		{Mbc3TimerRAMBattery, 0x03, &cartridge.MBC3{}},
+		{Mbc7SensorRumbleRAMBattery, 0x00, &cartridge.MBC7{}},
+		{Huc1RamBattery, 0x02, &cartridge.HuC1{}},
+	}
+	for _, c := range cases {
+		cart, err := NewCartridge(romImage(c.typ, 0x05, c.ramSize))
+		assert.NoError(t, err, "%s", c.typ)
+		assert.IsType(t, c.expected, cart, "%s", c.typ)
+		if c.typ != Mbc7SensorRumbleRAMBattery {
+			assert.Len(t, cart.RAM(), c.ramSize.Bytes(), "%s", c.typ)
+		}
+	}
+}
+
+func TestNewCartridgeServesROM(t *testing.T) {
+	rom := romImage(Mbc1, 0x01, 0x00)
+	rom[0x4000*3] = 0x42
+	cart, err := NewCartridge(rom)
+	assert.NoError(t, err)
+
+	cart.SetByte(0x2000, 0x03)
+	val, err := cart.GetByte(0x4000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x42), val)
+}
+
+func TestNewCartridgeValidatesSizes(t *testing.T) {
+	truncated := romImage(Mbc1, 0x02, 0x00)[:0x8000]
+	_, err := NewCartridge(truncated)
+	assert.True(t, errors.Is(err, ErrROMSizeMismatch))
+
+	_, err = NewCartridge(make([]byte, 0x100))
+	assert.True(t, errors.Is(err, ErrROMSizeMismatch))
+
+	_, err = NewCartridge(romImage(Mbc1Ram, 0x00, 0x00))
+	assert.True(t, errors.Is(err, ErrRAMSizeInvalid))
+
+	_, err = NewCartridge(romImage(Mbc1, 0x00, 0x02))
+	assert.True(t, errors.Is(err, ErrRAMSizeInvalid))
+}
+
+func TestNewCartridgeRejectsUnsupportedType(t *testing.T) {
+	_, err := NewCartridge(romImage(PocketCamera, 0x00, 0x00))
+	assert.True(t, errors.Is(err, ErrUnsupportedCartridge))
+}
@@ -0,0 +1,178 @@
+package cpu
+
+import "testing"
+
+// These tests check the ALU helpers against every possible pair of 8-bit inputs.
+// The expected flags are computed from an independent model based on the carry
+// chain (a ^ b ^ result) rather than re-using the nybble arithmetic in the helpers.
+
+type aluCase struct {
+	a, b    uint8
+	carryIn bool
+}
+
+func forEachALUInput(t *testing.T, fn func(c aluCase)) {
+	for a := 0; a <= 0xFF; a++ {
+		for b := 0; b <= 0xFF; b++ {
+			fn(aluCase{uint8(a), uint8(b), false})
+			fn(aluCase{uint8(a), uint8(b), true})
+		}
+	}
+}
+
+func modelFlags(zero, addSub, halfCarry, carry bool) Flags {
+	f := FlagEmpty
+	f.SetIf(zero, FlagZero)
+	f.SetIf(addSub, FlagAddSub)
+	f.SetIf(halfCarry, FlagHalfCarry)
+	f.SetIf(carry, FlagCarry)
+	return f
+}
+
+func checkALU(t *testing.T, name string, c aluCase, gotVal uint8, gotFlags Flags, wantVal uint8, wantFlags Flags) {
+	if gotVal != wantVal || gotFlags != wantFlags {
+		t.Fatalf("%s(0x%02X, 0x%02X, carry=%v) = 0x%02X [%08b], expected 0x%02X [%08b]",
+			name, c.a, c.b, c.carryIn, gotVal, gotFlags, wantVal, wantFlags)
+	}
+}
+
+func TestAdd8MatchesModel(t *testing.T) {
+	forEachALUInput(t, func(c aluCase) {
+		carry := 0
+		f := FlagEmpty
+		if c.carryIn {
+			carry = 1
+			f = FlagCarry
+		}
+		full := int(c.a) + int(c.b) + carry
+		want := uint8(full)
+		wantFlags := modelFlags(want == 0, false, (int(c.a)^int(c.b)^full)&0x10 != 0, full > 0xFF)
+
+		a := c.a
+		add8(&a, c.b, &f, true)
+		checkALU(t, "adc", c, a, f, want, wantFlags)
+	})
+}
+
+func TestAdd8WithoutCarryMatchesModel(t *testing.T) {
+	forEachALUInput(t, func(c aluCase) {
+		f := FlagEmpty
+		if c.carryIn {
+			// ADD must ignore an incoming carry flag entirely
+			f = FlagCarry
+		}
+		full := int(c.a) + int(c.b)
+		want := uint8(full)
+		wantFlags := modelFlags(want == 0, false, (int(c.a)^int(c.b)^full)&0x10 != 0, full > 0xFF)
+
+		a := c.a
+		add8(&a, c.b, &f, false)
+		checkALU(t, "add", c, a, f, want, wantFlags)
+	})
+}
+
+func TestSub8MatchesModel(t *testing.T) {
+	forEachALUInput(t, func(c aluCase) {
+		carry := 0
+		f := FlagEmpty
+		if c.carryIn {
+			carry = 1
+			f = FlagCarry
+		}
+		full := int(c.a) - int(c.b) - carry
+		want := uint8(full)
+		wantFlags := modelFlags(want == 0, true, (int(c.a)^int(c.b)^full)&0x10 != 0, full < 0)
+
+		a := c.a
+		sub8(&a, c.b, &f, true)
+		checkALU(t, "sbc", c, a, f, want, wantFlags)
+	})
+}
+
+func TestCpMatchesModel(t *testing.T) {
+	forEachALUInput(t, func(c aluCase) {
+		f := FlagEmpty
+		if c.carryIn {
+			// CP must ignore an incoming carry flag entirely
+			f = FlagCarry
+		}
+		full := int(c.a) - int(c.b)
+		wantFlags := modelFlags(uint8(full) == 0, true, (int(c.a)^int(c.b)^full)&0x10 != 0, full < 0)
+
+		cp(c.a, c.b, &f)
+		checkALU(t, "cp", c, c.a, f, c.a, wantFlags)
+	})
+}
+
+func TestAndMatchesModel(t *testing.T) {
+	forEachALUInput(t, func(c aluCase) {
+		f := FlagEmpty
+		if c.carryIn {
+			f = FlagCarry | FlagAddSub
+		}
+		want := c.a & c.b
+		wantFlags := modelFlags(want == 0, false, true, false)
+
+		a := c.a
+		and(&a, c.b, &f)
+		checkALU(t, "and", c, a, f, want, wantFlags)
+	})
+}
+
+func TestOrMatchesModel(t *testing.T) {
+	forEachALUInput(t, func(c aluCase) {
+		f := FlagEmpty
+		if c.carryIn {
+			f = FlagCarry | FlagHalfCarry | FlagAddSub
+		}
+		want := c.a | c.b
+		wantFlags := modelFlags(want == 0, false, false, false)
+
+		a := c.a
+		or(&a, c.b, &f)
+		checkALU(t, "or", c, a, f, want, wantFlags)
+	})
+}
+
+func TestXorMatchesModel(t *testing.T) {
+	forEachALUInput(t, func(c aluCase) {
+		f := FlagEmpty
+		if c.carryIn {
+			f = FlagCarry | FlagHalfCarry | FlagAddSub
+		}
+		want := c.a ^ c.b
+		wantFlags := modelFlags(want == 0, false, false, false)
+
+		a := c.a
+		xor(&a, c.b, &f)
+		checkALU(t, "xor", c, a, f, want, wantFlags)
+	})
+}
+
+func TestInc8AndDec8MatchModel(t *testing.T) {
+	for v := 0; v <= 0xFF; v++ {
+		for _, carryIn := range []bool{false, true} {
+			c := aluCase{uint8(v), 1, carryIn}
+			f := FlagEmpty
+			if carryIn {
+				f = FlagCarry
+			}
+
+			full := v + 1
+			want := uint8(full)
+			wantFlags := modelFlags(want == 0, false, (v^1^full)&0x10 != 0, carryIn)
+			val := c.a
+			g := f
+			inc8(&val, &g)
+			checkALU(t, "inc", c, val, g, want, wantFlags)
+
+			full = v - 1
+			want = uint8(full)
+			wantFlags = modelFlags(want == 0, true, (v^1^full)&0x10 != 0, carryIn)
+			val = c.a
+			g = f
+			dec8(&val, &g)
+			checkALU(t, "dec", c, val, g, want, wantFlags)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package cpu
+
+// Every memory access the CPU makes takes one M-cycle (4 T-states). Instructions are built out of
+// read, write and idle so that each access happens at the right point in the instruction: when an
+// access is made, State.TStates is the T-state at which that M-cycle begins.
+
+// read reads the byte at addr, taking one M-cycle.
+func (z *SM83) read(addr uint16) (uint8, error) {
+	val, err := z.Memory.GetByte(int(addr))
+	z.tick()
+	return val, err
+}
+
+// write writes val to addr, taking one M-cycle.
+func (z *SM83) write(addr uint16, val uint8) error {
+	err := z.Memory.SetByte(int(addr), val)
+	z.tick()
+	return err
+}
+
+// idle spends one M-cycle on internal work without accessing memory.
+func (z *SM83) idle() {
+	z.tick()
+}
+
+// tick ends an M-cycle, advancing the clock and the Ticker.
+func (z *SM83) tick() {
+	z.State.TStates += 4
+	if z.Ticker != nil {
+		z.Ticker.Tick()
+	}
+}
@@ -0,0 +1,154 @@
+package cpu
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// A timedAccess records a memory access and the T-state it was made at.
+type timedAccess struct {
+	Write bool
+	Addr  int
+	At    int
+}
+
+// timedMMU is a RAM that records the T-state of every access made by the CPU.
+type timedMMU struct {
+	*memory.RAM
+	z        *SM83
+	accesses []timedAccess
+}
+
+func (m *timedMMU) GetByte(addr int) (uint8, error) {
+	m.accesses = append(m.accesses, timedAccess{false, addr, m.z.State.TStates})
+	return m.RAM.GetByte(addr)
+}
+
+func (m *timedMMU) SetByte(addr int, val uint8) error {
+	m.accesses = append(m.accesses, timedAccess{true, addr, m.z.State.TStates})
+	return m.RAM.SetByte(addr, val)
+}
+
+func createTimedCPU(program ...byte) (*SM83, *timedMMU) {
+	z, ram := createCPU(program...)
+	mem := &timedMMU{RAM: ram, z: z}
+	z.Memory = mem
+	return z, mem
+}
+
+func TestCallAccessesMemoryOnTheRightCycles(t *testing.T) {
+	z, mem := createTimedCPU(0xCD, 0x34, 0x12) // CALL 0x1234
+	z.State.SP = 0xFFFE
+	step(t, z)
+	assert.Equal(t, []timedAccess{
+		{false, 0x0000, 0},
+		{false, 0x0001, 4},
+		{false, 0x0002, 8},
+		// One internal cycle, then the return address is pushed high byte first
+		{true, 0xFFFD, 16},
+		{true, 0xFFFC, 20},
+	}, mem.accesses)
+}
+
+func TestLoadFromAbsoluteAddressReadsOnTheLastCycle(t *testing.T) {
+	z, mem := createTimedCPU(0xFA, 0x00, 0xC0) // LD A,(0xC000)
+	step(t, z)
+	assert.Equal(t, timedAccess{false, 0xC000, 12}, mem.accesses[3])
+}
+
+func TestReadModifyWriteAccessesMemoryOnTheRightCycles(t *testing.T) {
+	z, mem := createTimedCPU(0xCB, 0x06) // RLC (HL)
+	z.State.H, z.State.L = 0x80, 0x00
+	step(t, z)
+	assert.Equal(t, []timedAccess{
+		{false, 0x0000, 0},
+		{false, 0x0001, 4},
+		{false, 0x8000, 8},
+		{true, 0x8000, 12},
+	}, mem.accesses)
+}
+
+func TestInterruptDispatchAccessesMemoryOnTheRightCycles(t *testing.T) {
+	z, mem := createTimedCPU()
+	z.State.PC = 0x0200
+	z.State.SP = 0xFFFE
+	z.State.IME = true
+	mem.SetByte(addrIE, uint8(InterruptVBlank))
+	mem.SetByte(addrIF, uint8(InterruptVBlank))
+	mem.accesses = nil
+
+	step(t, z)
+	var writes []timedAccess
+	for _, a := range mem.accesses {
+		if a.Write && a.Addr != addrIF {
+			writes = append(writes, a)
+		}
+	}
+	assert.Equal(t, []timedAccess{{true, 0xFFFD, 8}, {true, 0xFFFC, 12}}, writes)
+}
+
+// conditionFlags returns flags that make the branch condition cc evaluate to taken.
+func conditionFlags(cc uint8, taken bool) Flags {
+	for _, f := range []Flags{FlagEmpty, FlagZero | FlagCarry} {
+		if f.condition(cc) == taken {
+			return f
+		}
+	}
+	panic("unreachable")
+}
+
+func TestExecutedCyclesMatchOpcodeTable(t *testing.T) {
+	for op := 0; op < 0x100; op++ {
+		for _, taken := range []bool{false, true} {
+			// The CB prefix is covered by TestExecutedCBCyclesMatchOpcodeTable
+			if op == 0xCB || (taken && !Opcodes[op].Conditional()) {
+				continue
+			}
+			z, _ := createCPU(uint8(op), 0x00, 0x00)
+			z.State.SP = 0xFFFE
+			z.State.H = 0x80
+			z.State.F = conditionFlags(uint8(op>>3)&3, taken)
+
+			res, err := z.Step()
+			if errors.Is(err, ErrUnimplementedOpcode) || errors.Is(err, ErrLocked) {
+				continue
+			}
+			assert.NoError(t, err, "%02X %s", op, Opcodes[op])
+
+			expected := Opcodes[op].MinCycles
+			if taken {
+				expected = Opcodes[op].MaxCycles
+			}
+			assert.Equal(t, expected, res.Cycles, "%02X %s (taken: %v)", op, Opcodes[op], taken)
+			assert.Equal(t, taken, res.BranchTaken, "%02X %s (taken: %v)", op, Opcodes[op], taken)
+		}
+	}
+}
+
+func TestExecutedCBCyclesMatchOpcodeTable(t *testing.T) {
+	for op := 0; op < 0x100; op++ {
+		z, _ := createCPU(0xCB, uint8(op))
+		z.State.H = 0x80
+		assert.Equal(t, CBOpcodes[op].MinCycles, step(t, z), "CB %02X %s", op, CBOpcodes[op])
+	}
+}
+
+// countingTicker counts the M-cycles the CPU reports.
+type countingTicker struct {
+	ticks int
+}
+
+func (c *countingTicker) Tick() { c.ticks++ }
+
+func TestTickerAdvancesOncePerMCycle(t *testing.T) {
+	// CALL a16 takes 6 M-cycles
+	z, _ := createCPU(0xCD, 0x00, 0x10)
+	z.State.SP = 0xFFFE
+	ticker := &countingTicker{}
+	z.Ticker = ticker
+	assert.Equal(t, 24, step(t, z))
+	assert.Equal(t, 6, ticker.ticks)
+}
@@ -0,0 +1,455 @@
+package cpu
+
+// An instruction executes an opcode whose first byte has already been fetched. It spends its remaining
+// M-cycles through read, write and idle, so State.TStates ends up advanced by the cost listed in
+// Opcodes (MaxCycles when a conditional branch is taken).
+type instruction func(z *SM83, op uint8) error
+
+// instructions is the decoder: the implementation of every unprefixed opcode, indexed by opcode byte.
+// Decoding once up front keeps Step down to a single indexed call.
+var instructions = buildInstructions()
+
+// cbInstructions is the decoder for the CB-prefixed opcodes, indexed by the byte following the prefix.
+var cbInstructions = buildCBInstructions()
+
+// The rotate and shift operations of the CB-prefixed opcodes, indexed by bits 5-3 of the opcode.
+var cbShifts = [8]func(val *uint8, f *Flags){rlc, rrc, rl, rr, sla, sra, swap, srl}
+
+func buildInstructions() (table [256]instruction) {
+	for i := range table {
+		table[i] = decode(uint8(i))
+	}
+	return table
+}
+
+// decode selects the implementation of an unprefixed opcode from its bit fields.
+func decode(op uint8) instruction {
+	switch {
+	case op == 0x00:
+		return (*SM83).nop
+	case op == 0x76:
+		return (*SM83).haltOp
+	case op == 0xF3:
+		return (*SM83).di
+	case op == 0xFB:
+		return (*SM83).ei
+	case op == 0x10:
+		return (*SM83).stopOp
+	case op == 0xCB:
+		return (*SM83).prefixCB
+	case op >= 0x40 && op <= 0x7F:
+		return (*SM83).ldRR
+	case op&0xC7 == 0x06:
+		return (*SM83).ldRN8
+	case op&0xCF == 0x01:
+		return (*SM83).ldRPN16
+	case op&0xCF == 0x02:
+		return (*SM83).ldIndirectA
+	case op&0xCF == 0x0A:
+		return (*SM83).ldAIndirect
+	case op == 0x08:
+		return (*SM83).ldA16SP
+	case op == 0xE0, op == 0xF0:
+		return (*SM83).ldh
+	case op == 0xE2, op == 0xF2:
+		return (*SM83).ldhC
+	case op == 0xEA, op == 0xFA:
+		return (*SM83).ldA16
+	case op == 0xE8:
+		return (*SM83).addSPE8
+	case op == 0xF8:
+		return (*SM83).ldHLSPE8
+	case op == 0xF9:
+		return (*SM83).ldSPHL
+	case op&0xC7 == 0x04, op&0xC7 == 0x05:
+		return (*SM83).incDecR
+	case op&0xC7 == 0x03:
+		return (*SM83).incDecRP
+	case op&0xCF == 0x09:
+		return (*SM83).addHLRP
+	case op&0xE7 == 0x07:
+		return (*SM83).rotateAOp
+	case op&0xE7 == 0x27:
+		return (*SM83).accumulatorFlagOp
+	case op >= 0x80 && op <= 0xBF:
+		return (*SM83).aluR
+	case op&0xC7 == 0xC6:
+		return (*SM83).aluN8
+	case op == 0x18, op&0xE7 == 0x20:
+		return (*SM83).jrOp
+	case op == 0xC3, op&0xE7 == 0xC2:
+		return (*SM83).jp
+	case op == 0xE9:
+		return (*SM83).jpHL
+	case op == 0xCD, op&0xE7 == 0xC4:
+		return (*SM83).callOp
+	case op&0xE7 == 0xC0:
+		return (*SM83).retCC
+	case op == 0xC9, op == 0xD9:
+		return (*SM83).retOp
+	case op&0xC7 == 0xC7:
+		return (*SM83).rst
+	case op&0xCF == 0xC5:
+		return (*SM83).pushOp
+	case op&0xCF == 0xC1:
+		return (*SM83).popOp
+	default:
+		return (*SM83).unimplemented
+	}
+}
+
+func buildCBInstructions() (table [256]instruction) {
+	for i := range table {
+		table[i] = [4]instruction{(*SM83).cbShift, (*SM83).cbBit, (*SM83).cbRes, (*SM83).cbSet}[i>>6]
+	}
+	return table
+}
+
+// Unprefixed instructions, in the order they are decoded above
+
+func (z *SM83) nop(op uint8) error { return nil }
+
+func (z *SM83) unimplemented(op uint8) error { return ErrUnimplementedOpcode }
+
+// HALT
+func (z *SM83) haltOp(op uint8) error { return z.halt() }
+
+// DI
+func (z *SM83) di(op uint8) error {
+	z.State.IME = false
+	z.State.IMEPending = false
+	return nil
+}
+
+// EI
+func (z *SM83) ei(op uint8) error {
+	z.State.IMEPending = true
+	return nil
+}
+
+// STOP
+func (z *SM83) stopOp(op uint8) error { return z.stop() }
+
+// The CB prefix fetches and executes the second opcode byte
+func (z *SM83) prefixCB(op uint8) error {
+	op, err := z.fetch()
+	if err != nil {
+		return err
+	}
+	z.cbOpcode = op
+	return cbInstructions[op](z, op)
+}
+
+// LD r,r (0x76 is HALT, decoded separately)
+func (z *SM83) ldRR(op uint8) error {
+	val, err := z.getR(op & 7)
+	if err != nil {
+		return err
+	}
+	return z.setR((op>>3)&7, val)
+}
+
+// LD r,n8
+func (z *SM83) ldRN8(op uint8) error {
+	val, err := z.fetch()
+	if err != nil {
+		return err
+	}
+	return z.setR((op>>3)&7, val)
+}
+
+// LD rp,n16
+func (z *SM83) ldRPN16(op uint8) error {
+	val, err := z.fetch16()
+	if err != nil {
+		return err
+	}
+	z.setRP((op>>4)&3, val)
+	return nil
+}
+
+// LD (BC),A, LD (DE),A, LD (HL+),A, LD (HL-),A
+func (z *SM83) ldIndirectA(op uint8) error {
+	return z.write(z.indirectRP((op>>4)&3), z.State.A)
+}
+
+// LD A,(BC), LD A,(DE), LD A,(HL+), LD A,(HL-)
+func (z *SM83) ldAIndirect(op uint8) error {
+	return z.loadA(z.indirectRP((op>>4)&3), true)
+}
+
+// LD (a16),SP
+func (z *SM83) ldA16SP(op uint8) error {
+	addr, err := z.fetch16()
+	if err != nil {
+		return err
+	}
+	if err := z.write(addr, uint8(z.State.SP)); err != nil {
+		return err
+	}
+	return z.write(addr+1, uint8(z.State.SP>>8))
+}
+
+// LDH (a8),A, LDH A,(a8)
+func (z *SM83) ldh(op uint8) error {
+	n, err := z.fetch()
+	if err != nil {
+		return err
+	}
+	return z.loadA(0xFF00|uint16(n), op == 0xF0)
+}
+
+// LD (C),A, LD A,(C)
+func (z *SM83) ldhC(op uint8) error {
+	return z.loadA(0xFF00|uint16(z.State.C), op == 0xF2)
+}
+
+// LD (a16),A, LD A,(a16)
+func (z *SM83) ldA16(op uint8) error {
+	addr, err := z.fetch16()
+	if err != nil {
+		return err
+	}
+	return z.loadA(addr, op == 0xFA)
+}
+
+// ADD SP,e8
+func (z *SM83) addSPE8(op uint8) error {
+	offset, err := z.fetch()
+	if err != nil {
+		return err
+	}
+	z.State.SP = addSP(z.State.SP, offset, &z.State.F)
+	z.idle()
+	z.idle()
+	return nil
+}
+
+// LD HL,SP+e8
+func (z *SM83) ldHLSPE8(op uint8) error {
+	offset, err := z.fetch()
+	if err != nil {
+		return err
+	}
+	z.State.SetHL(addSP(z.State.SP, offset, &z.State.F))
+	z.idle()
+	return nil
+}
+
+// LD SP,HL
+func (z *SM83) ldSPHL(op uint8) error {
+	z.State.SP = z.State.HL()
+	z.idle()
+	return nil
+}
+
+// INC r, DEC r
+func (z *SM83) incDecR(op uint8) error {
+	r := (op >> 3) & 7
+	val, err := z.getR(r)
+	if err != nil {
+		return err
+	}
+	if op&1 == 0 {
+		inc8(&val, &z.State.F)
+	} else {
+		dec8(&val, &z.State.F)
+	}
+	return z.setR(r, val)
+}
+
+// INC rp, DEC rp
+func (z *SM83) incDecRP(op uint8) error {
+	p := (op >> 4) & 3
+	val := z.getRP(p)
+	z.incDec(val)
+	if op&0x08 == 0 {
+		inc16(&val)
+	} else {
+		dec16(&val)
+	}
+	z.setRP(p, val)
+	z.idle()
+	return nil
+}
+
+// ADD HL,rp
+func (z *SM83) addHLRP(op uint8) error {
+	hl := z.State.HL()
+	add16(&hl, z.getRP((op>>4)&3), &z.State.F)
+	z.State.SetHL(hl)
+	z.idle()
+	return nil
+}
+
+// RLCA, RRCA, RLA, RRA
+func (z *SM83) rotateAOp(op uint8) error {
+	rotateA(cbShifts[op>>3], &z.State.A, &z.State.F)
+	return nil
+}
+
+// DAA, CPL, SCF, CCF
+func (z *SM83) accumulatorFlagOp(op uint8) error {
+	switch op {
+	case 0x27:
+		daa(&z.State.A, &z.State.F)
+	case 0x2F:
+		cpl(&z.State.A, &z.State.F)
+	case 0x37:
+		scf(&z.State.F)
+	default:
+		ccf(&z.State.F)
+	}
+	return nil
+}
+
+// ALU A,r
+func (z *SM83) aluR(op uint8) error {
+	val, err := z.getR(op & 7)
+	if err != nil {
+		return err
+	}
+	z.alu((op>>3)&7, val)
+	return nil
+}
+
+// ALU A,n8
+func (z *SM83) aluN8(op uint8) error {
+	val, err := z.fetch()
+	if err != nil {
+		return err
+	}
+	z.alu((op>>3)&7, val)
+	return nil
+}
+
+// JR e8, JR cc,e8
+func (z *SM83) jrOp(op uint8) error {
+	offset, err := z.fetch()
+	if err != nil {
+		return err
+	}
+	if op == 0x18 || z.State.F.condition((op>>3)&3) {
+		jr(offset, &z.State.PC)
+		z.idle()
+	}
+	return nil
+}
+
+// JP a16, JP cc,a16
+func (z *SM83) jp(op uint8) error {
+	addr, err := z.fetch16()
+	if err != nil {
+		return err
+	}
+	if op == 0xC3 || z.State.F.condition((op>>3)&3) {
+		z.State.PC = addr
+		z.idle()
+	}
+	return nil
+}
+
+// JP HL
+func (z *SM83) jpHL(op uint8) error {
+	z.State.PC = z.State.HL()
+	return nil
+}
+
+// CALL a16, CALL cc,a16
+func (z *SM83) callOp(op uint8) error {
+	addr, err := z.fetch16()
+	if err != nil {
+		return err
+	}
+	if op == 0xCD || z.State.F.condition((op>>3)&3) {
+		return z.call(addr)
+	}
+	return nil
+}
+
+// RET cc, which spends an extra M-cycle evaluating the condition
+func (z *SM83) retCC(op uint8) error {
+	z.idle()
+	if z.State.F.condition((op >> 3) & 3) {
+		return z.ret()
+	}
+	return nil
+}
+
+// RET, RETI
+func (z *SM83) retOp(op uint8) error {
+	if err := z.ret(); err != nil {
+		return err
+	}
+	if op == 0xD9 {
+		// Unlike EI, RETI enables interrupts immediately
+		z.State.IME = true
+	}
+	return nil
+}
+
+// RST n
+func (z *SM83) rst(op uint8) error {
+	return z.call(uint16(op & 0x38))
+}
+
+// PUSH rp, where rp 3 is AF rather than SP
+func (z *SM83) pushOp(op uint8) error {
+	z.idle()
+	return z.push(z.getRP2((op >> 4) & 3))
+}
+
+// POP rp, where rp 3 is AF rather than SP
+func (z *SM83) popOp(op uint8) error {
+	val, err := z.pop()
+	if err != nil {
+		return err
+	}
+	z.setRP2((op>>4)&3, val)
+	return nil
+}
+
+// CB-prefixed instructions. Bits 5-3 select the operation or bit number, bits 2-0 the operand.
+
+// RLC, RRC, RL, RR, SLA, SRA, SWAP, SRL
+func (z *SM83) cbShift(op uint8) error {
+	r := op & 7
+	val, err := z.getR(r)
+	if err != nil {
+		return err
+	}
+	cbShifts[(op>>3)&7](&val, &z.State.F)
+	return z.setR(r, val)
+}
+
+// BIT n,r, which only tests the operand and doesn't write it back
+func (z *SM83) cbBit(op uint8) error {
+	val, err := z.getR(op & 7)
+	if err != nil {
+		return err
+	}
+	bit((op>>3)&7, val, &z.State.F)
+	return nil
+}
+
+// RES n,r
+func (z *SM83) cbRes(op uint8) error {
+	r := op & 7
+	val, err := z.getR(r)
+	if err != nil {
+		return err
+	}
+	res((op>>3)&7, &val)
+	return z.setR(r, val)
+}
+
+// SET n,r
+func (z *SM83) cbSet(op uint8) error {
+	r := op & 7
+	val, err := z.getR(r)
+	if err != nil {
+		return err
+	}
+	set((op>>3)&7, &val)
+	return z.setR(r, val)
+}
@@ -0,0 +1,37 @@
+package cpu
+
+import (
+	"testing"
+)
+
+// benchmarkProgram runs a program that loops forever, reporting the instructions executed per second.
+func benchmarkProgram(b *testing.B, program ...byte) {
+	z, _ := createCPU(program...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := z.Step(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "instr/s")
+}
+
+func BenchmarkStepNop(b *testing.B) {
+	benchmarkProgram(b, 0x00, 0x00, 0x00, 0x18, 0xFB) // NOP; NOP; NOP; JR -5
+}
+
+func BenchmarkStepMixed(b *testing.B) {
+	benchmarkProgram(b,
+		0x21, 0x00, 0xC0, // LD HL,0xC000
+		0x0E, 0x40, // LD C,0x40
+		0x3C,       // loop: INC A
+		0x80,       // ADD A,B
+		0x22,       // LD (HL+),A
+		0xCB, 0x37, // SWAP A
+		0x0D,       // DEC C
+		0x20, 0xF8, // JR NZ,loop
+		0xCD, 0x13, 0x00, // CALL sub
+		0xC3, 0x00, 0x00, // JP 0
+		0xC9, // sub: RET
+	)
+}
@@ -0,0 +1,70 @@
+package cpu
+
+import "fmt"
+
+// A StateDiff describes a single register, the clock or a CPU status flag that differs between two States.
+// Status flags are reported as 0 or 1.
+type StateDiff struct {
+	// The register name: A, F, B, C, D, E, H, L, PC, SP, TStates, or one of the status flags
+	// IME, IMEPending, Halted, HaltBug, Stopped, Locked and DoubleSpeed.
+	Register string
+	Old      int
+	New      int
+}
+
+func (d StateDiff) String() string {
+	switch d.Register {
+	case "F":
+		return fmt.Sprintf("F: %02X [%s] -> %02X [%s]", d.Old, Flags(d.Old), d.New, Flags(d.New))
+	case "PC", "SP":
+		return fmt.Sprintf("%s: %04X -> %04X", d.Register, d.Old, d.New)
+	case "TStates", "IME", "IMEPending", "Halted", "HaltBug", "Stopped", "Locked", "DoubleSpeed":
+		return fmt.Sprintf("%s: %d -> %d", d.Register, d.Old, d.New)
+	default:
+		return fmt.Sprintf("%s: %02X -> %02X", d.Register, d.Old, d.New)
+	}
+}
+
+// DiffState compares two States and returns the registers that differ, in AF, BC, DE, HL, SP, PC, TStates order,
+// followed by the status flags in IME, IMEPending, Halted, HaltBug, Stopped, Locked, DoubleSpeed order.
+// An empty result means the states are identical.
+func DiffState(old State, new State) []StateDiff {
+	fields := []struct {
+		name     string
+		old, new int
+	}{
+		{"A", int(old.A), int(new.A)},
+		{"F", int(old.F), int(new.F)},
+		{"B", int(old.B), int(new.B)},
+		{"C", int(old.C), int(new.C)},
+		{"D", int(old.D), int(new.D)},
+		{"E", int(old.E), int(new.E)},
+		{"H", int(old.H), int(new.H)},
+		{"L", int(old.L), int(new.L)},
+		{"SP", int(old.SP), int(new.SP)},
+		{"PC", int(old.PC), int(new.PC)},
+		{"TStates", old.TStates, new.TStates},
+		{"IME", boolToInt(old.IME), boolToInt(new.IME)},
+		{"IMEPending", boolToInt(old.IMEPending), boolToInt(new.IMEPending)},
+		{"Halted", boolToInt(old.Halted), boolToInt(new.Halted)},
+		{"HaltBug", boolToInt(old.HaltBug), boolToInt(new.HaltBug)},
+		{"Stopped", boolToInt(old.Stopped), boolToInt(new.Stopped)},
+		{"Locked", boolToInt(old.Locked), boolToInt(new.Locked)},
+		{"DoubleSpeed", boolToInt(old.DoubleSpeed), boolToInt(new.DoubleSpeed)},
+	}
+
+	var diffs []StateDiff
+	for _, f := range fields {
+		if f.old != f.new {
+			diffs = append(diffs, StateDiff{Register: f.name, Old: f.old, New: f.new})
+		}
+	}
+	return diffs
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
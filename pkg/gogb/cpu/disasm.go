@@ -0,0 +1,83 @@
+package cpu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTruncatedInstruction indicates that the bytes given to Disassemble end in the middle of an instruction.
+var ErrTruncatedInstruction = errors.New("instruction is truncated")
+
+// OpcodeInfo describes the unprefixed opcode op. For 0xCB, the prefix itself is described;
+// use CBOpcodeInfo for the instruction that follows it.
+//
+// This is the same table the CPU consults when fetching and tracing instructions, so tools
+// built on it always agree with the executor on lengths and timings.
+func OpcodeInfo(op byte) Opcode { return Opcodes[op] }
+
+// CBOpcodeInfo describes the instruction encoded by the byte op following a 0xCB prefix.
+func CBOpcodeInfo(op byte) Opcode { return CBOpcodes[op] }
+
+// instructionLength returns the full length in bytes of the instruction starting with op, including the CB prefix.
+func instructionLength(op byte) int {
+	if op == 0xCB {
+		return 2
+	}
+	return Opcodes[op].Length
+}
+
+// Disassemble decodes the instruction at the start of code, which is located at pc, and returns it in
+// assembly syntax together with its length in bytes. Relative jumps are shown with their absolute target.
+// Illegal opcodes disassemble as a single-byte "DB $xx".
+func Disassemble(pc uint16, code []byte) (string, int, error) {
+	if len(code) == 0 {
+		return "", 0, ErrTruncatedInstruction
+	}
+
+	length := instructionLength(code[0])
+	if len(code) < length {
+		return "", 0, ErrTruncatedInstruction
+	}
+
+	info := Opcodes[code[0]]
+	if code[0] == 0xCB {
+		info = CBOpcodes[code[1]]
+	}
+	if info.Illegal {
+		return fmt.Sprintf("DB $%02X", code[0]), length, nil
+	}
+	if len(info.Operands) == 0 {
+		return info.Mnemonic, length, nil
+	}
+
+	operands := make([]string, len(info.Operands))
+	for i, operand := range info.Operands {
+		operands[i] = formatOperand(operand, pc, code[:length])
+	}
+	return info.Mnemonic + " " + strings.Join(operands, ","), length, nil
+}
+
+// formatOperand renders operand with the immediate values taken from the instruction bytes in code.
+func formatOperand(operand Operand, pc uint16, code []byte) string {
+	switch operand.Kind {
+	case OperandImmediate8:
+		return fmt.Sprintf("$%02X", code[1])
+	case OperandImmediate16, OperandAddress16:
+		return fmt.Sprintf("$%04X", uint16(code[1])|uint16(code[2])<<8)
+	case OperandIndirectAddress16:
+		return fmt.Sprintf("($%04X)", uint16(code[1])|uint16(code[2])<<8)
+	case OperandHighAddress8:
+		return fmt.Sprintf("($FF%02X)", code[1])
+	case OperandSigned8:
+		if len(code) == 2 && (code[0] == 0x18 || code[0]&0xE7 == 0x20) {
+			// JR targets are relative to the end of the instruction
+			return fmt.Sprintf("$%04X", pc+2+uint16(int8(code[1])))
+		}
+		return fmt.Sprintf("%d", int8(code[1]))
+	case OperandSPOffset:
+		return fmt.Sprintf("SP%+d", int8(code[1]))
+	default:
+		return operand.Name
+	}
+}
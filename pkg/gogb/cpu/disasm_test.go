@@ -0,0 +1,92 @@
+package cpu
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisassemble(t *testing.T) {
+	cases := []struct {
+		code     []byte
+		expected string
+	}{
+		{[]byte{0x00}, "NOP"},
+		{[]byte{0x3E, 0x42}, "LD A,$42"},
+		{[]byte{0x21, 0x34, 0x12}, "LD HL,$1234"},
+		{[]byte{0x08, 0x00, 0xC0}, "LD ($C000),SP"},
+		{[]byte{0xEA, 0x00, 0xC0}, "LD ($C000),A"},
+		{[]byte{0xE0, 0x40}, "LDH ($FF40),A"},
+		{[]byte{0xF2}, "LDH A,(C)"},
+		{[]byte{0x22}, "LD (HL+),A"},
+		{[]byte{0x18, 0xFE}, "JR $0100"},
+		{[]byte{0x20, 0x10}, "JR NZ,$0112"},
+		{[]byte{0xC3, 0x50, 0x01}, "JP $0150"},
+		{[]byte{0xDC, 0x00, 0x40}, "CALL C,$4000"},
+		{[]byte{0xE8, 0xF0}, "ADD SP,-16"},
+		{[]byte{0xF8, 0x05}, "LD HL,SP+5"},
+		{[]byte{0xFF}, "RST $38"},
+		{[]byte{0xCB, 0x7C}, "BIT 7,H"},
+		{[]byte{0xCB, 0x36}, "SWAP (HL)"},
+		{[]byte{0xD3}, "DB $D3"},
+	}
+	for _, c := range cases {
+		text, length, err := Disassemble(0x0100, c.code)
+		assert.NoError(t, err, "% X", c.code)
+		assert.Equal(t, c.expected, text, "% X", c.code)
+		assert.Equal(t, len(c.code), length, "% X", c.code)
+	}
+}
+
+func FuzzDisassemble(f *testing.F) {
+	f.Add(uint16(0x0100), []byte{0x00})
+	f.Add(uint16(0x0100), []byte{0x18, 0xFE})
+	f.Add(uint16(0xFFFF), []byte{0x20, 0x7F})
+	f.Add(uint16(0x0000), []byte{0xCB})
+	f.Add(uint16(0x0000), []byte{})
+
+	f.Fuzz(func(t *testing.T, pc uint16, code []byte) {
+		text, length, err := Disassemble(pc, code)
+		if err != nil {
+			if !errors.Is(err, ErrTruncatedInstruction) {
+				t.Errorf("unexpected error for % X: %v", code, err)
+			}
+			return
+		}
+		if length < 1 || length > 3 || length > len(code) {
+			t.Errorf("invalid length %d for % X", length, code)
+		}
+		if text == "" {
+			t.Errorf("empty disassembly for % X", code)
+		}
+	})
+}
+
+func TestDisassembleTruncated(t *testing.T) {
+	for _, code := range [][]byte{{}, {0x3E}, {0xC3, 0x00}, {0xCB}} {
+		_, _, err := Disassemble(0, code)
+		assert.True(t, errors.Is(err, ErrTruncatedInstruction), "% X", code)
+	}
+}
+
+func TestExecutedLengthMatchesOpcodeTable(t *testing.T) {
+	jumps := map[string]bool{"JP": true, "JR": true, "CALL": true, "RET": true, "RETI": true, "RST": true}
+	for op := 0; op < 0x100; op++ {
+		info := OpcodeInfo(byte(op))
+		// Unconditional jumps don't fall through to the next instruction
+		if jumps[info.Mnemonic] && !info.Conditional() {
+			continue
+		}
+		z, _ := createCPU(uint8(op), 0x00, 0x00)
+		z.State.SP = 0xFFFE
+		z.State.F = conditionFlags(uint8(op>>3)&3, false)
+
+		_, err := z.Step()
+		if errors.Is(err, ErrUnimplementedOpcode) || errors.Is(err, ErrLocked) {
+			continue
+		}
+		assert.NoError(t, err, "%02X %s", op, info)
+		assert.Equal(t, uint16(instructionLength(byte(op))), z.State.PC, "%02X %s", op, info)
+	}
+}
@@ -0,0 +1,373 @@
+package cpu
+
+import "errors"
+
+// ErrUnimplementedOpcode occurs when the CPU fetches an opcode the decoder does not support.
+// Every legal opcode is now decoded, so Step no longer returns it; it is kept for existing callers.
+var ErrUnimplementedOpcode error = errors.New("unimplemented opcode")
+
+// ErrLocked occurs when the CPU executes one of the illegal opcodes, which hang the CPU on real hardware.
+// Once locked, every further Step fails with it as well; only resetting State.Locked recovers.
+var ErrLocked error = errors.New("cpu locked up by illegal opcode")
+
+// Addresses of the IO registers the CPU itself touches
+const (
+	addrDIV  = 0xFF04
+	addrIF   = 0xFF0F
+	addrKEY1 = 0xFF4D
+	addrIE   = 0xFFFF
+)
+
+// A StepResult describes what a single call to Step did.
+type StepResult struct {
+	// The value of PC when the step began.
+	PC uint16
+
+	// The opcode byte executed. For CB-prefixed instructions this is 0xCB and CBOpcode holds the byte that followed.
+	Opcode   uint8
+	CBOpcode uint8
+
+	// The number of instruction bytes consumed, or 0 if no instruction was executed, as when
+	// the CPU is halted or services an interrupt.
+	Length int
+
+	// The number of T-states the step took.
+	Cycles int
+
+	// Set when a conditional JR, JP, CALL or RET met its condition.
+	BranchTaken bool
+
+	// The interrupt serviced instead of executing an instruction, or 0 if none was.
+	Interrupt Interrupt
+}
+
+// Info returns the opcode table entry for the executed instruction.
+func (r StepResult) Info() Opcode {
+	if r.Opcode == 0xCB {
+		return CBOpcodes[r.CBOpcode]
+	}
+	return Opcodes[r.Opcode]
+}
+
+// Step executes a single instruction at PC and describes what it did. The T-states it took are
+// also added to State.TStates.
+// If IME is set and an enabled interrupt has been requested, the interrupt is serviced instead.
+// If the instruction cannot be executed, a *Fault is returned.
+func (z *SM83) Step() (StepResult, error) {
+	start := z.State.TStates
+	res := StepResult{PC: z.State.PC}
+	err := z.step(&res)
+	res.Cycles = z.State.TStates - start
+	if res.Length > 0 {
+		info := res.Info()
+		res.BranchTaken = info.Conditional() && res.Cycles == info.MaxCycles
+	}
+	return res, err
+}
+
+func (z *SM83) step(res *StepResult) error {
+	pc := z.State.PC
+	if z.State.Locked {
+		// The clock keeps running, but nothing (not even an interrupt) gets the CPU going again
+		z.idle()
+		op, _ := z.Memory.GetByte(int(pc))
+		return newFault(pc, op, ErrLocked)
+	}
+	if z.State.IME {
+		interrupt, err := z.pendingInterrupt()
+		if err != nil {
+			return newFault(pc, 0, err)
+		}
+		if interrupt != 0 {
+			serviced, err := z.dispatch()
+			if err != nil {
+				return newFault(pc, 0, err)
+			}
+			res.Interrupt = serviced
+			return nil
+		}
+	}
+
+	if z.State.Halted || z.State.Stopped {
+		// An enabled interrupt wakes the CPU even when IME is clear, in which case it is left pending
+		// and execution simply continues after the HALT or STOP
+		interrupt, err := z.pendingInterrupt()
+		if err != nil {
+			return newFault(pc, 0, err)
+		}
+		if interrupt != 0 {
+			z.State.Halted = false
+			z.State.Stopped = false
+		}
+		z.idle()
+		return nil
+	}
+
+	if z.OnExecute != nil {
+		z.OnExecute(pc, z.peekInstruction(pc), z.State)
+	}
+
+	// EI takes effect after the following instruction, unless that instruction is DI
+	enableIME := z.State.IMEPending
+	op, err := z.fetch()
+	if err != nil {
+		return newFault(pc, 0, err)
+	}
+	res.Opcode = op
+	if Opcodes[op].Illegal {
+		// PC is left on the illegal opcode so the lock-up is reported where it happened
+		z.State.PC = pc
+		z.State.Locked = true
+		return newFault(pc, op, ErrLocked)
+	}
+	if err := instructions[op](z, op); err != nil {
+		return newFault(pc, op, err)
+	}
+	res.Length = instructionLength(op)
+	if op == 0xCB {
+		res.CBOpcode = z.cbOpcode
+	}
+	if enableIME && z.State.IMEPending {
+		z.State.IME = true
+		z.State.IMEPending = false
+	}
+	return nil
+}
+
+// peekInstruction returns the bytes of the instruction at pc without spending any cycles.
+// Bytes that cannot be read are returned as 0xFF, like an open bus.
+func (z *SM83) peekInstruction(pc uint16) []byte {
+	op, err := z.Memory.GetByte(int(pc))
+	if err != nil {
+		op = 0xFF
+	}
+	length := instructionLength(op)
+	out := make([]byte, length)
+	out[0] = op
+	for i := 1; i < length; i++ {
+		val, err := z.Memory.GetByte(int(pc) + i)
+		if err != nil {
+			val = 0xFF
+		}
+		out[i] = val
+	}
+	return out
+}
+
+// fetch reads the byte at PC and advances PC past it, taking one M-cycle.
+// After the HALT bug is triggered, PC fails to advance once.
+func (z *SM83) fetch() (uint8, error) {
+	val, err := z.read(z.State.PC)
+	if z.State.HaltBug {
+		z.State.HaltBug = false
+	} else {
+		z.State.PC++
+	}
+	return val, err
+}
+
+// fetch16 reads the little-endian word at PC and advances PC past it, taking 2 M-cycles.
+func (z *SM83) fetch16() (uint16, error) {
+	lo, err := z.fetch()
+	if err != nil {
+		return 0, err
+	}
+	hi, err := z.fetch()
+	return uint16(hi)<<8 | uint16(lo), err
+}
+
+// loadA copies between A and addr: into A if toA is set, otherwise from A into addr.
+func (z *SM83) loadA(addr uint16, toA bool) error {
+	if toA {
+		val, err := z.read(addr)
+		if err != nil {
+			return err
+		}
+		z.State.A = val
+		return nil
+	}
+	return z.write(addr, z.State.A)
+}
+
+// ret pops PC from the stack. It takes 3 M-cycles: the pop followed by an internal delay.
+func (z *SM83) ret() error {
+	addr, err := z.pop()
+	if err != nil {
+		return err
+	}
+	z.State.PC = addr
+	z.idle()
+	return nil
+}
+
+// halt executes HALT. If IME is clear and an enabled interrupt is already pending, the CPU doesn't halt at all;
+// instead the HALT bug is triggered and the byte after HALT is read twice.
+func (z *SM83) halt() error {
+	if !z.State.IME {
+		interrupt, err := z.pendingInterrupt()
+		if err != nil {
+			return err
+		}
+		if interrupt != 0 {
+			z.State.HaltBug = true
+			return nil
+		}
+	}
+	z.State.Halted = true
+	return nil
+}
+
+// stop executes STOP. The second byte of the instruction is read through the bus and discarded, but
+// it doesn't take a bus cycle of its own, and DIV is reset. On a CGB with the KEY1 switch armed, the
+// CPU toggles between normal and double speed instead of entering low-power mode.
+func (z *SM83) stop() error {
+	if _, err := z.Memory.GetByte(int(z.State.PC)); err != nil {
+		return err
+	}
+	z.State.PC++
+	if err := z.Memory.SetByte(addrDIV, 0); err != nil {
+		return err
+	}
+
+	if z.CGB {
+		key1, err := z.Memory.GetByte(addrKEY1)
+		if err != nil {
+			return err
+		}
+		if key1&0x01 != 0 {
+			// KEY1 reports the new speed from State.DoubleSpeed; only the switch needs disarming
+			z.State.DoubleSpeed = !z.State.DoubleSpeed
+			return z.Memory.SetByte(addrKEY1, 0)
+		}
+	}
+
+	z.State.Stopped = true
+	return nil
+}
+
+// condition evaluates the branch condition selected by a 2-bit operand field (NZ, Z, NC, C).
+func (f Flags) condition(cc uint8) bool {
+	switch cc {
+	case 0:
+		return f.IsClear(FlagZero)
+	case 1:
+		return f.IsSet(FlagZero)
+	case 2:
+		return f.IsClear(FlagCarry)
+	default:
+		return f.IsSet(FlagCarry)
+	}
+}
+
+// alu applies the ALU operation selected by bits 5-3 of an opcode (ADD, ADC, SUB, SBC, AND, XOR, OR, CP) to A.
+func (z *SM83) alu(y uint8, val uint8) {
+	a := &z.State.A
+	f := &z.State.F
+	switch y {
+	case 0:
+		add8(a, val, f, false)
+	case 1:
+		add8(a, val, f, true)
+	case 2:
+		sub8(a, val, f, false)
+	case 3:
+		sub8(a, val, f, true)
+	case 4:
+		and(a, val, f)
+	case 5:
+		xor(a, val, f)
+	case 6:
+		or(a, val, f)
+	case 7:
+		cp(*a, val, f)
+	}
+}
+
+// getRP reads the register pair selected by a 2-bit operand field (BC, DE, HL, SP).
+func (z *SM83) getRP(p uint8) uint16 {
+	switch p {
+	case 0:
+		return z.State.BC()
+	case 1:
+		return z.State.DE()
+	case 2:
+		return z.State.HL()
+	default:
+		return z.State.SP
+	}
+}
+
+// setRP writes the register pair selected by a 2-bit operand field (BC, DE, HL, SP).
+func (z *SM83) setRP(p uint8, val uint16) {
+	switch p {
+	case 0:
+		z.State.SetBC(val)
+	case 1:
+		z.State.SetDE(val)
+	case 2:
+		z.State.SetHL(val)
+	default:
+		z.State.SP = val
+	}
+}
+
+// getRP2 reads a register pair as encoded by PUSH and POP, where 3 selects AF instead of SP.
+func (z *SM83) getRP2(p uint8) uint16 {
+	if p == 3 {
+		return z.State.AF()
+	}
+	return z.getRP(p)
+}
+
+// setRP2 writes a register pair as encoded by PUSH and POP. Writing AF masks the low nibble of F.
+func (z *SM83) setRP2(p uint8, val uint16) {
+	if p == 3 {
+		z.State.SetAF(val)
+	} else {
+		z.setRP(p, val)
+	}
+}
+
+// indirectRP returns the address selected by a 2-bit operand field of the LD (rp),A and LD A,(rp)
+// instructions ((BC), (DE), (HL+), (HL-)), incrementing or decrementing HL for the last two.
+func (z *SM83) indirectRP(p uint8) uint16 {
+	switch p {
+	case 0:
+		return z.State.BC()
+	case 1:
+		return z.State.DE()
+	case 2:
+		hl := z.State.HL()
+		z.incDec(hl)
+		z.State.SetHL(hl + 1)
+		return hl
+	default:
+		hl := z.State.HL()
+		z.incDec(hl)
+		z.State.SetHL(hl - 1)
+		return hl
+	}
+}
+
+// reg8 returns the register selected by a 3-bit operand field (B, C, D, E, H, L, -, A).
+// Index 6 selects (HL), which is not a register; use getR/setR to handle it.
+func (z *SM83) reg8(r uint8) *uint8 {
+	return [8]*uint8{&z.State.B, &z.State.C, &z.State.D, &z.State.E, &z.State.H, &z.State.L, nil, &z.State.A}[r]
+}
+
+// getR reads the operand selected by a 3-bit operand field, including (HL).
+func (z *SM83) getR(r uint8) (uint8, error) {
+	if r == 6 {
+		return z.read(z.State.HL())
+	}
+	return *z.reg8(r), nil
+}
+
+// setR writes the operand selected by a 3-bit operand field, including (HL).
+func (z *SM83) setR(r uint8, val uint8) error {
+	if r == 6 {
+		return z.write(z.State.HL(), val)
+	}
+	*z.reg8(r) = val
+	return nil
+}
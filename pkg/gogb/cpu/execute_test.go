@@ -0,0 +1,722 @@
+package cpu
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+	"github.com/anurse/gogb/pkg/gogb/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// createCPU returns a SM83 with the program loaded at address 0, and PC pointing at it.
+// The RAM covers the whole address space, so IO registers such as IF and IE can be reached.
+func createCPU(program ...byte) (*SM83, *memory.RAM) {
+	mem := memory.NewRAM(0x10000)
+	for i, b := range program {
+		mem.SetByte(i, b)
+	}
+	z := NewSM83(&mem)
+	return &z, &mem
+}
+
+// step executes a single instruction, failing the test if it faults, and returns the T-states it took.
+func step(t *testing.T, z *SM83) int {
+	t.Helper()
+	res, err := z.Step()
+	assert.NoError(t, err)
+	return res.Cycles
+}
+
+func TestStepExecutesNop(t *testing.T) {
+	z, _ := createCPU(0x00)
+	assert.Equal(t, 4, step(t, z))
+	assert.Equal(t, uint16(1), z.State.PC)
+	assert.Equal(t, 4, z.State.TStates)
+}
+
+func TestStepImplementsEveryLegalOpcode(t *testing.T) {
+	for op := 0; op < 0x100; op++ {
+		if Opcodes[op].Illegal {
+			continue
+		}
+		z, _ := createCPU(uint8(op), 0x00, 0x00)
+		z.State.SP = 0xFFFE
+		_, err := z.Step()
+		assert.False(t, errors.Is(err, ErrUnimplementedOpcode), "%02X %s", op, Opcodes[op])
+	}
+}
+
+func TestStepLocksUpOnIllegalOpcode(t *testing.T) {
+	z, _ := createCPU(0x00, 0xD3)
+	step(t, z)
+
+	_, err := z.Step()
+	var fault *Fault
+	assert.True(t, errors.As(err, &fault))
+	assert.Equal(t, FaultIllegalOpcode, fault.Kind)
+	assert.Equal(t, uint16(0x0001), fault.PC)
+	assert.Equal(t, uint8(0xD3), fault.Opcode)
+	assert.True(t, errors.Is(err, ErrLocked))
+	assert.True(t, z.State.Locked)
+	assert.Equal(t, uint16(0x0001), z.State.PC)
+	assert.EqualError(t, err, "cpu fault IllegalOpcode at PC=0x0001 (opcode 0xD3): cpu locked up by illegal opcode")
+}
+
+func TestLockedCPUIgnoresInterrupts(t *testing.T) {
+	z, mem := createCPU(0xFD) // illegal
+	z.State.SP = 0xFFFE
+	_, err := z.Step()
+	assert.True(t, errors.Is(err, ErrLocked))
+
+	z.State.IME = true
+	mem.SetByte(addrIE, uint8(InterruptVBlank))
+	mem.SetByte(addrIF, uint8(InterruptVBlank))
+	res, err := z.Step()
+	assert.True(t, errors.Is(err, ErrLocked))
+	assert.Equal(t, 4, res.Cycles)
+	assert.Equal(t, uint16(0x0000), z.State.PC)
+	assert.Equal(t, uint16(0xFFFE), z.State.SP)
+}
+
+// INC/DEC instructions
+func TestStepExecutesIncAndDecOnRegisters(t *testing.T) {
+	z, _ := createCPU(0x04, 0x0D, 0x3C) // INC B; DEC C; INC A
+	z.State.B, z.State.C, z.State.A = 0x0F, 0x01, 0xFF
+	for i := 0; i < 3; i++ {
+		step(t, z)
+	}
+	assert.Equal(t, uint8(0x10), z.State.B)
+	assert.Equal(t, uint8(0x00), z.State.C)
+	assert.Equal(t, uint8(0x00), z.State.A)
+	assert.Equal(t, 12, z.State.TStates)
+}
+
+func TestStepExecutesIncOnMemory(t *testing.T) {
+	z, mem := createCPU(0x34) // INC (HL)
+	mem.SetByte(0x0800, 0x41)
+	z.State.H, z.State.L = 0x08, 0x00
+	step(t, z)
+
+	val, _ := mem.GetByte(0x0800)
+	assert.Equal(t, uint8(0x42), val)
+	assert.Equal(t, 12, z.State.TStates)
+}
+
+func TestStepExecutesIncAndDecOnRegisterPairs(t *testing.T) {
+	z, _ := createCPU(0x03, 0x1B, 0x23, 0x3B) // INC BC; DEC DE; INC HL; DEC SP
+	z.State.B, z.State.C = 0x00, 0xFF
+	z.State.H, z.State.L = 0xFF, 0xFF
+	z.State.F = FlagZero
+	for i := 0; i < 4; i++ {
+		step(t, z)
+	}
+	assert.Equal(t, uint16(0x0100), z.getRP(0))
+	assert.Equal(t, uint16(0xFFFF), z.getRP(1))
+	assert.Equal(t, uint16(0x0000), z.State.HL())
+	assert.Equal(t, uint16(0xFFFF), z.State.SP)
+	assert.Equal(t, FlagZero, z.State.F)
+	assert.Equal(t, 32, z.State.TStates)
+}
+
+func TestStepExecutesAddHLRegisterPair(t *testing.T) {
+	z, _ := createCPU(0x09, 0x29, 0x39) // ADD HL,BC; ADD HL,HL; ADD HL,SP
+	z.State.SetHL(0x0FFF)
+	z.State.SetBC(0x0001)
+	z.State.SP = 0xE000
+	z.State.F = FlagZero | FlagAddSub
+
+	assert.Equal(t, 8, step(t, z))
+	assert.Equal(t, uint16(0x1000), z.State.HL())
+	assert.Equal(t, FlagZero|FlagHalfCarry, z.State.F)
+
+	assert.Equal(t, 8, step(t, z))
+	assert.Equal(t, uint16(0x2000), z.State.HL())
+	assert.Equal(t, FlagZero, z.State.F)
+
+	assert.Equal(t, 8, step(t, z))
+	assert.Equal(t, uint16(0x0000), z.State.HL())
+	assert.Equal(t, FlagZero|FlagCarry, z.State.F)
+}
+
+func TestStepExecutesPushAndPop(t *testing.T) {
+	z, mem := createCPU(0xC5, 0xD1, 0xE5, 0xF1) // PUSH BC; POP DE; PUSH HL; POP AF
+	z.State.SP = 0xD000
+	z.State.SetBC(0x1234)
+	z.State.SetHL(0xABCD)
+
+	assert.Equal(t, 16, step(t, z))
+	val, _ := mem.GetWord(0xCFFE)
+	assert.Equal(t, uint16(0x1234), val)
+	assert.Equal(t, uint16(0xCFFE), z.State.SP)
+
+	assert.Equal(t, 12, step(t, z))
+	assert.Equal(t, uint16(0x1234), z.State.DE())
+	assert.Equal(t, uint16(0xD000), z.State.SP)
+
+	step(t, z)
+	step(t, z)
+	assert.Equal(t, uint16(0xABC0), z.State.AF(), "POP AF masks the low nibble of F")
+}
+
+func TestStepPushesAFRatherThanSP(t *testing.T) {
+	z, mem := createCPU(0xF5) // PUSH AF
+	z.State.SP = 0xD000
+	z.State.SetAF(0x42B0)
+	step(t, z)
+	val, _ := mem.GetWord(0xCFFE)
+	assert.Equal(t, uint16(0x42B0), val)
+}
+
+func TestStepExecutesAccumulatorFlagOps(t *testing.T) {
+	z, _ := createCPU(0x2F, 0x37, 0x3F, 0x3F) // CPL; SCF; CCF; CCF
+	z.State.A = 0x35
+	z.State.F = FlagZero
+
+	assert.Equal(t, 4, step(t, z))
+	assert.Equal(t, uint8(0xCA), z.State.A)
+	assert.Equal(t, FlagZero|FlagAddSub|FlagHalfCarry, z.State.F)
+
+	step(t, z)
+	assert.Equal(t, FlagZero|FlagCarry, z.State.F)
+	step(t, z)
+	assert.Equal(t, FlagZero, z.State.F)
+	step(t, z)
+	assert.Equal(t, FlagZero|FlagCarry, z.State.F)
+}
+
+func TestStepExecutesDAAAfterAddition(t *testing.T) {
+	z, _ := createCPU(0xC6, 0x38, 0x27) // ADD A,0x38; DAA
+	z.State.A = 0x45
+	step(t, z)
+	assert.Equal(t, 4, step(t, z))
+	assert.Equal(t, uint8(0x83), z.State.A)
+	assert.Equal(t, FlagEmpty, z.State.F)
+}
+
+// Accumulator rotates
+func TestStepExecutesAccumulatorRotates(t *testing.T) {
+	z, _ := createCPU(0x07, 0x0F, 0x17, 0x1F) // RLCA; RRCA; RLA; RRA
+	z.State.A = 0x81
+	step(t, z)
+	assert.Equal(t, uint8(0x03), z.State.A)
+	assert.Equal(t, FlagCarry, z.State.F)
+
+	step(t, z)
+	assert.Equal(t, uint8(0x81), z.State.A)
+	assert.Equal(t, FlagCarry, z.State.F)
+
+	step(t, z)
+	assert.Equal(t, uint8(0x03), z.State.A)
+	assert.Equal(t, FlagCarry, z.State.F)
+
+	step(t, z)
+	assert.Equal(t, uint8(0x81), z.State.A)
+	assert.Equal(t, FlagCarry, z.State.F)
+	assert.Equal(t, 16, z.State.TStates)
+}
+
+func TestAccumulatorRotateDiffersFromCBRotateOnZero(t *testing.T) {
+	z, _ := createCPU(0x17, 0xCB, 0x17) // RLA; RL A
+	z.State.A = 0x80
+	step(t, z)
+	assert.True(t, z.State.F.IsClear(FlagZero))
+
+	z.State.A = 0x80
+	z.State.F = FlagEmpty
+	step(t, z)
+	assert.True(t, z.State.F.IsSet(FlagZero))
+}
+
+// ALU instructions
+func TestStepExecutesALUOnRegister(t *testing.T) {
+	z, _ := createCPU(0xB0, 0xA8) // OR B; XOR B
+	z.State.A, z.State.B = 0x0F, 0xF0
+	step(t, z)
+	assert.Equal(t, uint8(0xFF), z.State.A)
+	step(t, z)
+	assert.Equal(t, uint8(0x0F), z.State.A)
+	assert.Equal(t, 8, z.State.TStates)
+}
+
+func TestStepExecutesALUOnMemory(t *testing.T) {
+	z, mem := createCPU(0x96) // SUB (HL)
+	mem.SetByte(0x0800, 0x02)
+	z.State.A, z.State.H, z.State.L = 0x44, 0x08, 0x00
+	step(t, z)
+	assert.Equal(t, uint8(0x42), z.State.A)
+	assert.True(t, z.State.F.IsSet(FlagAddSub))
+	assert.Equal(t, 8, z.State.TStates)
+}
+
+func TestStepExecutesALUOnImmediate(t *testing.T) {
+	z, _ := createCPU(0xEE, 0xFF, 0xFE, 0xF0) // XOR $FF; CP $F0
+	z.State.A = 0x0F
+	step(t, z)
+	assert.Equal(t, uint8(0xF0), z.State.A)
+	step(t, z)
+	assert.True(t, z.State.F.IsSet(FlagZero))
+	assert.Equal(t, uint8(0xF0), z.State.A)
+	assert.Equal(t, uint16(4), z.State.PC)
+	assert.Equal(t, 16, z.State.TStates)
+}
+
+func TestStepDecodesEveryALUOperation(t *testing.T) {
+	// ADD, ADC, SUB, SBC, AND, XOR, OR, CP with B=0x01 starting from A=0x03, carry set
+	expected := []uint8{0x04, 0x05, 0x02, 0x01, 0x01, 0x02, 0x03, 0x03}
+	for y, want := range expected {
+		z, _ := createCPU(0x80 | byte(y)<<3)
+		z.State.A, z.State.B, z.State.F = 0x03, 0x01, FlagCarry
+		step(t, z)
+		assert.Equal(t, want, z.State.A, "ALU operation %d", y)
+	}
+}
+
+// CB-prefixed instructions
+func TestStepExecutesCBOnRegister(t *testing.T) {
+	z, _ := createCPU(0xCB, 0x37) // SWAP A
+	z.State.A = 0xA5
+	step(t, z)
+	assert.Equal(t, uint8(0x5A), z.State.A)
+	assert.Equal(t, uint16(2), z.State.PC)
+	assert.Equal(t, 8, z.State.TStates)
+}
+
+func TestStepExecutesCBOnMemory(t *testing.T) {
+	z, mem := createCPU(0xCB, 0xFE) // SET 7,(HL)
+	z.State.H, z.State.L = 0x08, 0x00
+	step(t, z)
+
+	val, err := mem.GetByte(0x0800)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x80), val)
+	assert.Equal(t, 16, z.State.TStates)
+}
+
+func TestStepExecutesBitOnMemoryWithoutWriting(t *testing.T) {
+	z, _ := createCPU(0xCB, 0x46) // BIT 0,(HL)
+	rec := memory.NewRecordingMMU(z.Memory)
+	z.Memory = rec
+	z.State.H, z.State.L = 0x08, 0x00
+	step(t, z)
+
+	assert.True(t, z.State.F.IsSet(FlagZero))
+	assert.Equal(t, 12, z.State.TStates)
+	for _, access := range rec.Accesses {
+		assert.Equal(t, memory.AccessRead, access.Kind)
+	}
+}
+
+func TestStepDecodesEveryCBRegister(t *testing.T) {
+	// SET 0 on B, C, D, E, H, L, (HL), A in turn
+	program := []byte{}
+	for r := byte(0); r < 8; r++ {
+		program = append(program, 0xCB, 0xC0|r)
+	}
+	z, mem := createCPU(program...)
+	for i := 0; i < 8; i++ {
+		step(t, z)
+	}
+
+	// H and L were set to 0x01 before (HL) was used, so (HL) is 0x0101
+	hlVal, _ := mem.GetByte(0x0101)
+	assert.Equal(t, []uint8{1, 1, 1, 1, 1, 1, 1}, []uint8{z.State.B, z.State.C, z.State.D, z.State.E, z.State.H, z.State.L, z.State.A})
+	assert.Equal(t, uint8(0x01), hlVal)
+}
+
+// Jumps, calls and returns
+func TestStepExecutesRelativeJumps(t *testing.T) {
+	z, _ := createCPU(0x18, 0x02, 0x00, 0x00, 0x18, 0xFA) // JR +2; NOP; NOP; JR -6
+	step(t, z)
+	assert.Equal(t, uint16(0x0004), z.State.PC)
+	step(t, z)
+	assert.Equal(t, uint16(0x0000), z.State.PC)
+	assert.Equal(t, 24, z.State.TStates)
+}
+
+func TestStepChargesConditionalJumpsByOutcome(t *testing.T) {
+	z, _ := createCPU(0x20, 0x10, 0x28, 0x10) // JR NZ,+16; JR Z,+16
+	z.State.F = FlagZero
+
+	assert.Equal(t, 8, step(t, z))
+	assert.Equal(t, uint16(0x0002), z.State.PC)
+	assert.Equal(t, 8, z.State.TStates)
+
+	assert.Equal(t, 12, step(t, z))
+	assert.Equal(t, uint16(0x0014), z.State.PC)
+	assert.Equal(t, 20, z.State.TStates)
+}
+
+func TestStepExecutesAbsoluteJumps(t *testing.T) {
+	z, _ := createCPU(0xD2, 0x00, 0x01, 0xDA, 0x34, 0x02) // JP NC,0x0100; JP C,0x0234
+	z.State.F = FlagCarry
+
+	step(t, z)
+	assert.Equal(t, uint16(0x0003), z.State.PC)
+	assert.Equal(t, 12, z.State.TStates)
+
+	step(t, z)
+	assert.Equal(t, uint16(0x0234), z.State.PC)
+	assert.Equal(t, 28, z.State.TStates)
+}
+
+func TestStepExecutesJumpToHL(t *testing.T) {
+	z, _ := createCPU(0xE9) // JP HL
+	z.State.H, z.State.L = 0x04, 0x56
+	step(t, z)
+	assert.Equal(t, uint16(0x0456), z.State.PC)
+	assert.Equal(t, 4, z.State.TStates)
+}
+
+func TestStepExecutesConditionalCallAndReturn(t *testing.T) {
+	z, mem := createCPU(0xC4, 0x00, 0x01, 0xCC, 0x00, 0x01) // CALL NZ,0x0100; CALL Z,0x0100
+	mem.SetByte(0x0100, 0xC0)                               // RET NZ
+	mem.SetByte(0x0101, 0xC8)                               // RET Z
+	z.State.SP = 0x0FFE
+	z.State.F = FlagZero
+
+	// CALL NZ is not taken
+	step(t, z)
+	assert.Equal(t, uint16(0x0003), z.State.PC)
+	assert.Equal(t, uint16(0x0FFE), z.State.SP)
+	assert.Equal(t, 12, z.State.TStates)
+
+	// CALL Z is taken and pushes the return address
+	step(t, z)
+	assert.Equal(t, uint16(0x0100), z.State.PC)
+	assert.Equal(t, uint16(0x0FFC), z.State.SP)
+	assert.Equal(t, 36, z.State.TStates)
+	ret, _ := mem.GetWord(0x0FFC)
+	assert.Equal(t, uint16(0x0006), ret)
+
+	// RET NZ is not taken
+	step(t, z)
+	assert.Equal(t, uint16(0x0101), z.State.PC)
+	assert.Equal(t, 44, z.State.TStates)
+
+	// RET Z is taken
+	step(t, z)
+	assert.Equal(t, uint16(0x0006), z.State.PC)
+	assert.Equal(t, uint16(0x0FFE), z.State.SP)
+	assert.Equal(t, 64, z.State.TStates)
+}
+
+func TestStepExecutesReturn(t *testing.T) {
+	z, mem := createCPU(0xC9) // RET
+	z.State.SP = 0x0FFC
+	mem.SetWord(0x0FFC, 0x0123)
+	step(t, z)
+	assert.Equal(t, uint16(0x0123), z.State.PC)
+	assert.Equal(t, uint16(0x0FFE), z.State.SP)
+	assert.False(t, z.State.IME)
+	assert.Equal(t, 16, z.State.TStates)
+}
+
+func TestStepExecutesReturnFromInterrupt(t *testing.T) {
+	z, mem := createCPU(0xD9) // RETI
+	z.State.SP = 0x0FFC
+	mem.SetWord(0x0FFC, 0x0123)
+	step(t, z)
+	assert.Equal(t, uint16(0x0123), z.State.PC)
+	assert.Equal(t, uint16(0x0FFE), z.State.SP)
+	assert.True(t, z.State.IME)
+	assert.Equal(t, 16, z.State.TStates)
+}
+
+func TestStepExecutesEveryRestart(t *testing.T) {
+	for vec := uint16(0x00); vec <= 0x38; vec += 0x08 {
+		z, mem := createCPU()
+		mem.SetByte(0x0200, uint8(0xC7|vec)) // RST vec
+		z.State.PC = 0x0200
+		z.State.SP = 0x0FFE
+		step(t, z)
+		assert.Equal(t, vec, z.State.PC)
+		assert.Equal(t, uint16(0x0FFC), z.State.SP)
+		ret, _ := mem.GetWord(0x0FFC)
+		assert.Equal(t, uint16(0x0201), ret)
+		assert.Equal(t, 16, z.State.TStates)
+	}
+}
+
+func TestStepRestartAndReturnRoundTrip(t *testing.T) {
+	z, mem := createCPU()
+	mem.SetByte(0x0200, 0xEF) // RST 0x28
+	mem.SetByte(0x0028, 0xC9) // RET
+	z.State.PC = 0x0200
+	z.State.SP = 0x0FFE
+	step(t, z)
+	step(t, z)
+	assert.Equal(t, uint16(0x0201), z.State.PC)
+	assert.Equal(t, uint16(0x0FFE), z.State.SP)
+}
+
+// STOP
+func createStopCPU(cgb bool, key1 uint8) (*SM83, *memory.RAM) {
+	mem := memory.NewRAM(0x10000)
+	mem.SetByte(0x0000, 0x10) // STOP
+	mem.SetByte(0x0001, 0x00)
+	mem.SetByte(addrDIV, 0xAB)
+	mem.SetByte(addrKEY1, key1)
+	z := NewSM83(&mem)
+	z.CGB = cgb
+	return &z, &mem
+}
+
+func TestStepExecutesStop(t *testing.T) {
+	z, mem := createStopCPU(false, 0x01)
+	step(t, z)
+	assert.Equal(t, uint16(0x0002), z.State.PC)
+	assert.True(t, z.State.Stopped)
+	assert.False(t, z.State.DoubleSpeed)
+
+	div, _ := mem.GetByte(addrDIV)
+	assert.Equal(t, uint8(0x00), div)
+
+	// Nothing executes while stopped
+	step(t, z)
+	assert.Equal(t, uint16(0x0002), z.State.PC)
+	assert.Equal(t, 8, z.State.TStates)
+}
+
+func TestStepStopWithoutArmedKEY1DoesNotSwitchSpeed(t *testing.T) {
+	z, _ := createStopCPU(true, 0x00)
+	step(t, z)
+	assert.True(t, z.State.Stopped)
+	assert.False(t, z.State.DoubleSpeed)
+}
+
+func TestStepStopSwitchesSpeedOnCGB(t *testing.T) {
+	z, mem := createStopCPU(true, 0x01)
+	step(t, z)
+	assert.False(t, z.State.Stopped)
+	assert.True(t, z.State.DoubleSpeed)
+	key1, _ := mem.GetByte(addrKEY1)
+	assert.Equal(t, uint8(0x00), key1, "the switch is disarmed")
+
+	// Switching again returns to normal speed
+	z.State.PC = 0
+	mem.SetByte(addrKEY1, 0x01)
+	step(t, z)
+	assert.False(t, z.State.DoubleSpeed)
+}
+
+func TestKEY1ReportsSpeedFromCPUOnBus(t *testing.T) {
+	bus := memory.NewBus(model.CGB)
+	z := NewSM83(bus)
+	z.CGB = true
+	bus.IO.(*memory.IORegisters).Map(memory.IOSpeed, NewKEY1(&z))
+	for i, b := range []byte{0x10, 0x00, 0x10, 0x00} { // STOP; STOP
+		bus.SetByte(memory.WRAMStart+i, b)
+	}
+	z.State.PC = memory.WRAMStart
+	key1 := func() uint8 {
+		val, err := bus.GetByte(addrKEY1)
+		assert.NoError(t, err)
+		return val
+	}
+
+	// Bit 7 can't be written
+	bus.SetByte(addrKEY1, 0x80)
+	assert.Equal(t, uint8(0x7E), key1())
+
+	bus.SetByte(addrKEY1, 0x01)
+	assert.Equal(t, uint8(0x7F), key1())
+	step(t, &z)
+	assert.True(t, z.State.DoubleSpeed)
+	assert.Equal(t, uint8(0xFE), key1())
+
+	// Arming after switching keeps reporting double speed
+	bus.SetByte(addrKEY1, 0x01)
+	assert.Equal(t, uint8(0xFF), key1())
+	step(t, &z)
+	assert.False(t, z.State.DoubleSpeed)
+	assert.Equal(t, uint8(0x7E), key1())
+}
+
+func TestStepStopReadsOperandThroughBus(t *testing.T) {
+	mem := memory.NewRAM(0x0001)
+	mem.SetByte(0x0000, 0x10) // STOP, with its operand past the end of memory
+	z := NewSM83(&mem)
+	_, err := z.Step()
+	assert.True(t, errors.Is(err, memory.ErrAddressOutOfRange))
+	assert.False(t, z.State.Stopped)
+}
+
+// Loads
+func TestStepExecutesRegisterLoads(t *testing.T) {
+	z, mem := createCPU(0x41, 0x70, 0x7E) // LD B,C; LD (HL),B; LD A,(HL)
+	z.State.C = 0x42
+	z.State.SetHL(0x8000)
+	step(t, z)
+	assert.Equal(t, uint8(0x42), z.State.B)
+	step(t, z)
+	val, _ := mem.GetByte(0x8000)
+	assert.Equal(t, uint8(0x42), val)
+	step(t, z)
+	assert.Equal(t, uint8(0x42), z.State.A)
+	assert.Equal(t, 20, z.State.TStates)
+}
+
+func TestStepExecutesImmediateLoads(t *testing.T) {
+	z, mem := createCPU(0x1E, 0x99, 0x36, 0x77, 0x31, 0xFE, 0xFF) // LD E,0x99; LD (HL),0x77; LD SP,0xFFFE
+	z.State.SetHL(0x8000)
+	step(t, z)
+	assert.Equal(t, uint8(0x99), z.State.E)
+	step(t, z)
+	val, _ := mem.GetByte(0x8000)
+	assert.Equal(t, uint8(0x77), val)
+	step(t, z)
+	assert.Equal(t, uint16(0xFFFE), z.State.SP)
+	assert.Equal(t, 32, z.State.TStates)
+}
+
+func TestStepExecutesIndirectLoads(t *testing.T) {
+	z, mem := createCPU(0x02, 0x1A, 0x22, 0x3A) // LD (BC),A; LD A,(DE); LD (HL+),A; LD A,(HL-)
+	mem.SetByte(0x9000, 0x55)
+	mem.SetByte(0xA001, 0x66)
+	z.State.A = 0x11
+	z.State.SetBC(0x8000)
+	z.State.SetDE(0x9000)
+	z.State.SetHL(0xA000)
+
+	step(t, z)
+	val, _ := mem.GetByte(0x8000)
+	assert.Equal(t, uint8(0x11), val)
+
+	step(t, z)
+	assert.Equal(t, uint8(0x55), z.State.A)
+
+	step(t, z)
+	val, _ = mem.GetByte(0xA000)
+	assert.Equal(t, uint8(0x55), val)
+	assert.Equal(t, uint16(0xA001), z.State.HL())
+
+	step(t, z)
+	assert.Equal(t, uint8(0x66), z.State.A)
+	assert.Equal(t, uint16(0xA000), z.State.HL())
+}
+
+func TestStepExecutesStackPointerLoads(t *testing.T) {
+	z, mem := createCPU(0x08, 0x00, 0xC0, 0xF9) // LD (0xC000),SP; LD SP,HL
+	z.State.SP = 0xBEEF
+	z.State.SetHL(0xD000)
+
+	assert.Equal(t, 20, step(t, z))
+	val, _ := mem.GetWord(0xC000)
+	assert.Equal(t, uint16(0xBEEF), val)
+
+	assert.Equal(t, 8, step(t, z))
+	assert.Equal(t, uint16(0xD000), z.State.SP)
+}
+
+func TestStepExecutesHighPageLoads(t *testing.T) {
+	z, mem := createCPU(0xE0, 0x80, 0xF2, 0xEA, 0x00, 0xC0) // LDH (0x80),A; LD A,(C); LD (0xC000),A
+	mem.SetByte(0xFF10, 0x33)
+	z.State.A = 0x44
+	z.State.C = 0x10
+
+	assert.Equal(t, 12, step(t, z))
+	val, _ := mem.GetByte(0xFF80)
+	assert.Equal(t, uint8(0x44), val)
+
+	assert.Equal(t, 8, step(t, z))
+	assert.Equal(t, uint8(0x33), z.State.A)
+
+	assert.Equal(t, 16, step(t, z))
+	val, _ = mem.GetByte(0xC000)
+	assert.Equal(t, uint8(0x33), val)
+}
+
+func TestStepDecodesEveryLoad(t *testing.T) {
+	for op, info := range Opcodes {
+		if info.Mnemonic != "LD" && info.Mnemonic != "LDH" {
+			continue
+		}
+		z, _ := createCPU(uint8(op), 0x00, 0xC0)
+		_, err := z.Step()
+		assert.NoError(t, err, "%02X %s", op, info)
+	}
+}
+
+func TestStepExecutesStackPointerOffsets(t *testing.T) {
+	z, _ := createCPU(0xE8, 0xFE, 0xF8, 0x02) // ADD SP,-2; LD HL,SP+2
+	z.State.SP = 0xC002
+	z.State.F = FlagZero | FlagAddSub
+
+	assert.Equal(t, 16, step(t, z))
+	assert.Equal(t, uint16(0xC000), z.State.SP)
+	assert.Equal(t, FlagHalfCarry|FlagCarry, z.State.F)
+
+	assert.Equal(t, 12, step(t, z))
+	assert.Equal(t, uint16(0xC002), z.State.HL())
+	assert.Equal(t, uint16(0xC000), z.State.SP)
+	assert.Equal(t, FlagEmpty, z.State.F)
+}
+
+// Execution hook
+func TestOnExecuteSeesEveryInstructionBeforeItRuns(t *testing.T) {
+	z, _ := createCPU(0x3E, 0x42, 0xCB, 0x37, 0x00) // LD A,0x42; SWAP A; NOP
+	type call struct {
+		PC     uint16
+		Opcode []byte
+		A      uint8
+	}
+	var calls []call
+	z.OnExecute = func(pc uint16, opcode []byte, state State) {
+		calls = append(calls, call{pc, append([]byte(nil), opcode...), state.A})
+	}
+	for i := 0; i < 3; i++ {
+		step(t, z)
+	}
+	assert.Equal(t, []call{
+		{0x0000, []byte{0x3E, 0x42}, 0x00},
+		{0x0002, []byte{0xCB, 0x37}, 0x42},
+		{0x0004, []byte{0x00}, 0x24},
+	}, calls)
+
+	// Peeking at the instruction bytes doesn't cost any cycles
+	assert.Equal(t, 20, z.State.TStates)
+}
+
+func TestOnExecuteIsNotCalledForInterruptDispatch(t *testing.T) {
+	z, mem := createCPU()
+	z.State.SP = 0xFFFE
+	z.State.IME = true
+	mem.SetByte(addrIE, uint8(InterruptVBlank))
+	mem.SetByte(addrIF, uint8(InterruptVBlank))
+	called := false
+	z.OnExecute = func(uint16, []byte, State) { called = true }
+	step(t, z)
+	assert.False(t, called)
+}
+
+func TestStepResultDescribesInstruction(t *testing.T) {
+	z, _ := createCPU(0x3E, 0x42, 0xCB, 0x37, 0x20, 0x05)
+	z.State.F = FlagZero
+
+	res, err := z.Step()
+	assert.NoError(t, err)
+	assert.Equal(t, StepResult{PC: 0x0000, Opcode: 0x3E, Length: 2, Cycles: 8}, res)
+
+	res, err = z.Step()
+	assert.NoError(t, err)
+	assert.Equal(t, StepResult{PC: 0x0002, Opcode: 0xCB, CBOpcode: 0x37, Length: 2, Cycles: 8}, res)
+	assert.Equal(t, "SWAP", res.Info().Mnemonic)
+
+	// SWAP A of 0x42 leaves Z clear, so JR NZ is taken
+	res, err = z.Step()
+	assert.NoError(t, err)
+	assert.Equal(t, StepResult{PC: 0x0004, Opcode: 0x20, Length: 2, Cycles: 12, BranchTaken: true}, res)
+}
+
+func TestStepResultReportsServicedInterrupt(t *testing.T) {
+	z, mem := createCPU(0x00)
+	z.State.SP = 0xFFFE
+	z.State.IME = true
+	mem.SetByte(addrIE, uint8(InterruptTimer))
+	mem.SetByte(addrIF, uint8(InterruptTimer))
+
+	res, err := z.Step()
+	assert.NoError(t, err)
+	assert.Equal(t, StepResult{PC: 0x0000, Cycles: 20, Interrupt: InterruptTimer}, res)
+}
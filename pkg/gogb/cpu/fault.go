@@ -0,0 +1,77 @@
+package cpu
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+)
+
+// A FaultKind identifies the kind of runtime fault that stopped the CPU.
+type FaultKind uint8
+
+// Values for FaultKind
+const (
+	// A push would have moved the stack pointer below the bottom of memory.
+	FaultStackOverflow FaultKind = iota
+	// A pop would have read past the end of memory.
+	FaultStackUnderflow
+	// An instruction accessed an address the MMU does not map.
+	FaultBusError
+	// The CPU executed an illegal opcode and locked up.
+	FaultIllegalOpcode
+	// The fault was caused by some other error returned from the MMU.
+	FaultOther
+)
+
+func (k FaultKind) String() string {
+	return [...]string{"StackOverflow", "StackUnderflow", "BusError", "IllegalOpcode", "Other"}[k]
+}
+
+// A Fault describes a runtime error that stopped the CPU while executing an instruction.
+// Faults are the only errors surfaced by the execution core, so embedders can use errors.As
+// to handle every failure uniformly. The underlying error (if any) is available through Unwrap.
+type Fault struct {
+	Kind FaultKind
+
+	// The address of the instruction that faulted.
+	PC uint16
+
+	// The opcode of the instruction that faulted.
+	Opcode uint8
+
+	// The error that caused the fault, such as ErrStackOverflow or memory.ErrAddressOutOfRange.
+	Err error
+}
+
+func (f *Fault) Error() string {
+	if f.Err == nil {
+		return fmt.Sprintf("cpu fault %s at PC=0x%04X (opcode 0x%02X)", f.Kind, f.PC, f.Opcode)
+	}
+	return fmt.Sprintf("cpu fault %s at PC=0x%04X (opcode 0x%02X): %v", f.Kind, f.PC, f.Opcode, f.Err)
+}
+
+// Unwrap returns the error that caused the fault.
+func (f *Fault) Unwrap() error { return f.Err }
+
+// newFault converts an error returned by an instruction helper into a Fault.
+// If err is already a Fault, it is returned unchanged.
+func newFault(pc uint16, opcode uint8, err error) *Fault {
+	var fault *Fault
+	if errors.As(err, &fault) {
+		return fault
+	}
+
+	kind := FaultOther
+	switch {
+	case errors.Is(err, ErrStackOverflow):
+		kind = FaultStackOverflow
+	case errors.Is(err, ErrStackUnderflow):
+		kind = FaultStackUnderflow
+	case errors.Is(err, memory.ErrAddressOutOfRange):
+		kind = FaultBusError
+	case errors.Is(err, ErrLocked):
+		kind = FaultIllegalOpcode
+	}
+	return &Fault{Kind: kind, PC: pc, Opcode: opcode, Err: err}
+}
@@ -0,0 +1,28 @@
+package cpu
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFaultClassifiesHelperErrors(t *testing.T) {
+	assert.Equal(t, FaultStackOverflow, newFault(0, 0, ErrStackOverflow).Kind)
+	assert.Equal(t, FaultStackUnderflow, newFault(0, 0, ErrStackUnderflow).Kind)
+	assert.Equal(t, FaultBusError, newFault(0, 0, memory.ErrAddressOutOfRange).Kind)
+	assert.Equal(t, FaultIllegalOpcode, newFault(0, 0, ErrLocked).Kind)
+	assert.Equal(t, FaultOther, newFault(0, 0, errors.New("boom")).Kind)
+}
+
+func TestNewFaultDoesNotRewrapFaults(t *testing.T) {
+	inner := &Fault{Kind: FaultIllegalOpcode, PC: 0x1234, Opcode: 0xD3}
+	assert.Same(t, inner, newFault(0, 0, inner))
+}
+
+func TestFaultUnwrapsToCause(t *testing.T) {
+	var err error = newFault(0x0150, 0xC5, ErrStackOverflow)
+	assert.True(t, errors.Is(err, ErrStackOverflow))
+	assert.EqualError(t, err, "cpu fault StackOverflow at PC=0x0150 (opcode 0xC5): stack overflow")
+}
@@ -0,0 +1,124 @@
+package cpu
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+	"github.com/anurse/gogb/pkg/gogb/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// Run `go test ./pkg/gogb/cpu -run TestGoldenTraces -update` to regenerate the golden traces
+// after an intentional change in CPU behavior. Review the diff before committing it!
+var update = flag.Bool("update", false, "regenerate the golden trace files in testdata")
+
+// goldenTraces lists the test ROMs that are traced and the most instructions each may run before
+// it has to report its result. Every instruction is folded into a running CRC, and every `every`
+// instructions a line is written with the state at that point and the CRC of the instructions
+// since the previous line. A divergence in any instruction therefore changes the next line, while
+// the golden file stays small enough to review.
+var goldenTraces = []struct {
+	rom    string
+	golden string
+	steps  int
+	every  int
+}{
+	{"cpu_instrs/06-ld r,r.gb", "06-ld-r-r.trace", 400000, 1000},
+}
+
+// Serial port registers. The flat RAM used here has no serial port, so a transfer is picked up by
+// polling SC after every instruction.
+const (
+	regSB = 0xFF01
+	regSC = 0xFF02
+)
+
+// traceROM runs the ROM on a DMG from the post-boot state until it prints "Passed" or "Failed" on
+// the serial port, and returns the trace and everything the ROM printed. The test fails if the CPU
+// faults or the ROM doesn't finish within steps instructions.
+func traceROM(t *testing.T, path string, steps int, every int) ([]byte, string) {
+	rom, err := os.ReadFile(filepath.Join("..", "..", "..", "testroms", path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem := memory.NewRAM(0x10000)
+	for i, b := range rom {
+		if i < 0x8000 {
+			mem.SetByte(i, b)
+		}
+	}
+	z := NewSM83(&mem)
+	z.Reset(model.DMG)
+
+	var trace bytes.Buffer
+	var line string
+	digest := crc32.NewIEEE()
+	z.OnExecute = func(pc uint16, opcode []byte, state State) {
+		line = fmt.Sprintf("%s T=%d (% X)", state, state.TStates, opcode)
+		fmt.Fprintln(digest, line)
+	}
+
+	var serial strings.Builder
+	for i := 1; i <= steps; i++ {
+		if _, err := z.Step(); err != nil {
+			t.Fatalf("after %d instructions: %v\nserial output: %q", i, err, serial.String())
+		}
+		if i%every == 0 {
+			fmt.Fprintf(&trace, "%s crc=%08X\n", line, digest.Sum32())
+			digest.Reset()
+		}
+		if sc, _ := mem.GetByte(regSC); sc == 0x81 {
+			sb, _ := mem.GetByte(regSB)
+			serial.WriteByte(sb)
+			mem.SetByte(regSC, 0x01)
+		}
+		if out := serial.String(); strings.Contains(out, "Passed") || strings.Contains(out, "Failed") {
+			if i%every != 0 {
+				fmt.Fprintf(&trace, "%s crc=%08X\n", line, digest.Sum32())
+			}
+			return trace.Bytes(), out
+		}
+	}
+	t.Fatalf("ROM did not finish within %d instructions\nserial output: %q", steps, serial.String())
+	return nil, ""
+}
+
+func TestGoldenTraces(t *testing.T) {
+	for _, tt := range goldenTraces {
+		t.Run(tt.golden, func(t *testing.T) {
+			actual, serial := traceROM(t, tt.rom, tt.steps, tt.every)
+			assert.Contains(t, serial, "Passed")
+
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, actual, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+
+			// Compare line by line so a failure points at the window of instructions that diverged
+			expectedLines := bytes.Split(expected, []byte("\n"))
+			actualLines := bytes.Split(actual, []byte("\n"))
+			for i := 0; i < len(expectedLines) && i < len(actualLines); i++ {
+				if !bytes.Equal(expectedLines[i], actualLines[i]) {
+					assert.Equal(t, string(expectedLines[i]), string(actualLines[i]),
+						"trace diverges within instructions %d-%d", i*tt.every+1, (i+1)*tt.every)
+					return
+				}
+			}
+			assert.Equal(t, len(expectedLines), len(actualLines), "trace length")
+		})
+	}
+}
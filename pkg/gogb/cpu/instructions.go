@@ -6,47 +6,113 @@ import (
 	"github.com/anurse/gogb/pkg/gogb/memory"
 )
 
-// ErrStackOverflow occurs when a push would cause the stack pointer to wrap around.
+// ErrStackOverflow occurs in strict stack mode when a push would cause the stack pointer to wrap around.
 var ErrStackOverflow error = errors.New("stack overflow")
 
-// ErrStackUnderflow occurs when a push would cause the stack pointer to wrap around.
+// ErrStackUnderflow occurs in strict stack mode when a pop would cause the stack pointer to wrap around,
+// or would read past the end of memory.
 var ErrStackUnderflow error = errors.New("stack underflow")
 
-func push(val uint16, sp *uint16, mem memory.MMU) error {
-	if *sp <= 2 {
+// push writes val to the stack, high byte first, taking 2 M-cycles.
+// SP wraps around below 0x0000 unless StrictStack is set.
+func (z *SM83) push(val uint16) error {
+	if z.StrictStack && z.State.SP < 2 {
 		return ErrStackOverflow
 	}
-	*sp -= 2
-	return mem.SetWord(int(*sp), val)
+	if err := z.pushByte(uint8(val >> 8)); err != nil {
+		return err
+	}
+	return z.pushByte(uint8(val))
 }
 
-func pop(sp *uint16, mem memory.MMU) (uint16, error) {
-	res, err := mem.GetWord(int(*sp))
-	if errors.Is(err, memory.ErrAddressOutOfRange) {
+// pushByte decrements SP and writes val to the stack, taking one M-cycle.
+func (z *SM83) pushByte(val uint8) error {
+	z.State.SP--
+	return z.write(z.State.SP, val)
+}
+
+// pop reads a word from the stack, low byte first, taking 2 M-cycles.
+// SP wraps around above 0xFFFF unless StrictStack is set. SP is left unchanged if either read fails.
+func (z *SM83) pop() (uint16, error) {
+	sp := z.State.SP
+	if z.StrictStack && sp > 0xFFFE {
+		return 0, ErrStackUnderflow
+	}
+	lo, err := z.read(sp)
+	var hi uint8
+	if err == nil {
+		hi, err = z.read(sp + 1)
+	}
+	if z.StrictStack && errors.Is(err, memory.ErrAddressOutOfRange) {
 		return 0, ErrStackUnderflow
+	} else if err != nil {
+		return 0, err
 	}
-	*sp += 2
-	return res, err
+	z.State.SP = sp + 2
+	return uint16(hi)<<8 | uint16(lo), nil
 }
 
-func add8(left *uint8, right uint8, f *Z80Flags, withCarry bool) {
+func add8(left *uint8, right uint8, f *Flags, withCarry bool) {
+	// The carry-in has to take part in both the nybble and full sums, so keep it separate
+	// rather than folding it into right (which could wrap 0xFF to 0x00).
+	var carry uint8
 	if withCarry && f.IsSet(FlagCarry) {
-		right++
+		carry = 1
 	}
 
-	result := int(*left) + int(right)
+	result := int(*left) + int(right) + int(carry)
 
 	f.SetIf(result > 0xFF, FlagCarry)
 	result = result & 0xFF
 
 	f.Clear(FlagAddSub)
 	f.SetIf(result == 0, FlagZero)
-	f.SetIf((right&0x0F)+(*left&0x0F) > 0x0F, FlagHalfCarry)
+	f.SetIf((right&0x0F)+(*left&0x0F)+carry > 0x0F, FlagHalfCarry)
 
 	*left = uint8(result & 0xFF)
 }
 
-func add16(left *uint16, right uint16, f *Z80Flags) {
+func sub8(left *uint8, right uint8, f *Flags, withCarry bool) {
+	var carry uint8
+	if withCarry && f.IsSet(FlagCarry) {
+		carry = 1
+	}
+
+	result := int(*left) - int(right) - int(carry)
+
+	// Carry and HalfCarry are set when a borrow is needed, from bit 8 and bit 4 respectively
+	f.SetIf(result < 0, FlagCarry)
+	f.SetIf(int(*left&0x0F)-int(right&0x0F)-int(carry) < 0, FlagHalfCarry)
+	f.Set(FlagAddSub)
+	f.SetIf(uint8(result) == 0, FlagZero)
+
+	*left = uint8(result)
+}
+
+func cp(left uint8, right uint8, f *Flags) {
+	sub8(&left, right, f, false)
+}
+
+func inc8(val *uint8, f *Flags) {
+	f.SetIf(*val&0x0F == 0x0F, FlagHalfCarry)
+	*val++
+	f.SetIf(*val == 0, FlagZero)
+	f.Clear(FlagAddSub)
+}
+
+func dec8(val *uint8, f *Flags) {
+	f.SetIf(*val&0x0F == 0x00, FlagHalfCarry)
+	*val--
+	f.SetIf(*val == 0, FlagZero)
+	f.Set(FlagAddSub)
+}
+
+// inc16 and dec16 don't affect any flags, so they don't take them
+func inc16(val *uint16) { *val++ }
+
+func dec16(val *uint16) { *val-- }
+
+func add16(left *uint16, right uint16, f *Flags) {
 	result := int(*left) + int(right)
 
 	f.SetIf(result > 0xFFFF, FlagCarry)
@@ -58,7 +124,17 @@ func add16(left *uint16, right uint16, f *Z80Flags) {
 	*left = uint16(result & 0xFFFF)
 }
 
-func and(left *uint8, right uint8, f *Z80Flags) {
+// addSP returns SP plus a signed 8-bit offset, as computed by ADD SP,e8 and LD HL,SP+e8.
+// Unlike add16, the half carry and carry come from adding the offset's unsigned byte to the
+// low byte of SP, and Z is always cleared.
+func addSP(sp uint16, offset uint8, f *Flags) uint16 {
+	f.Clear(FlagZero | FlagAddSub)
+	f.SetIf((sp&0x0F)+uint16(offset&0x0F) > 0x0F, FlagHalfCarry)
+	f.SetIf((sp&0xFF)+uint16(offset) > 0xFF, FlagCarry)
+	return uint16(int(sp) + int(int8(offset)))
+}
+
+func and(left *uint8, right uint8, f *Flags) {
 	*left = *left & right
 	f.SetIf(*left == 0, FlagZero)
 	f.Clear(FlagAddSub)
@@ -66,15 +142,154 @@ func and(left *uint8, right uint8, f *Z80Flags) {
 	f.Clear(FlagCarry)
 }
 
-func bit(b uint8, val uint8, f *Z80Flags) {
+func or(left *uint8, right uint8, f *Flags) {
+	*left = *left | right
+	f.SetIf(*left == 0, FlagZero)
+	f.Clear(FlagAddSub)
+	f.Clear(FlagHalfCarry)
+	f.Clear(FlagCarry)
+}
+
+func xor(left *uint8, right uint8, f *Flags) {
+	*left = *left ^ right
+	f.SetIf(*left == 0, FlagZero)
+	f.Clear(FlagAddSub)
+	f.Clear(FlagHalfCarry)
+	f.Clear(FlagCarry)
+}
+
+func bit(b uint8, val uint8, f *Flags) {
 	f.SetIf(val&(1<<b) == 0, FlagZero)
+	f.Clear(FlagAddSub)
+	f.Set(FlagHalfCarry)
 }
 
-func call(addr uint16, pc *uint16, sp *uint16, mem memory.MMU) error {
-	err := push(*pc, sp, mem)
-	if err != nil {
+func res(b uint8, val *uint8) { *val &= ^(1 << b) }
+
+func set(b uint8, val *uint8) { *val |= 1 << b }
+
+// shiftFlags sets the flags for the CB-prefixed rotate and shift instructions,
+// which set Zero from the result and Carry from the bit shifted out.
+func shiftFlags(result uint8, carry bool, f *Flags) {
+	f.SetIf(result == 0, FlagZero)
+	f.Clear(FlagAddSub)
+	f.Clear(FlagHalfCarry)
+	f.SetIf(carry, FlagCarry)
+}
+
+func rlc(val *uint8, f *Flags) {
+	carry := *val&0x80 != 0
+	*val = *val<<1 | *val>>7
+	shiftFlags(*val, carry, f)
+}
+
+func rrc(val *uint8, f *Flags) {
+	carry := *val&0x01 != 0
+	*val = *val>>1 | *val<<7
+	shiftFlags(*val, carry, f)
+}
+
+func rl(val *uint8, f *Flags) {
+	carry := *val&0x80 != 0
+	*val = *val << 1
+	if f.IsSet(FlagCarry) {
+		*val |= 0x01
+	}
+	shiftFlags(*val, carry, f)
+}
+
+func rr(val *uint8, f *Flags) {
+	carry := *val&0x01 != 0
+	*val = *val >> 1
+	if f.IsSet(FlagCarry) {
+		*val |= 0x80
+	}
+	shiftFlags(*val, carry, f)
+}
+
+func sla(val *uint8, f *Flags) {
+	carry := *val&0x80 != 0
+	*val = *val << 1
+	shiftFlags(*val, carry, f)
+}
+
+func sra(val *uint8, f *Flags) {
+	carry := *val&0x01 != 0
+	*val = *val>>1 | *val&0x80
+	shiftFlags(*val, carry, f)
+}
+
+func swap(val *uint8, f *Flags) {
+	*val = *val<<4 | *val>>4
+	shiftFlags(*val, false, f)
+}
+
+func srl(val *uint8, f *Flags) {
+	carry := *val&0x01 != 0
+	*val = *val >> 1
+	shiftFlags(*val, carry, f)
+}
+
+func jr(offset uint8, pc *uint16) {
+	*pc = uint16(int(*pc) + int(int8(offset)))
+}
+
+// call pushes PC and jumps to addr. It takes 3 M-cycles: an internal delay followed by the push.
+func (z *SM83) call(addr uint16) error {
+	z.idle()
+	if err := z.push(z.State.PC); err != nil {
 		return err
 	}
-	*pc = addr
+	z.State.PC = addr
 	return nil
 }
+
+// rotateA performs RLCA, RRCA, RLA or RRA using the matching CB rotate.
+// Unlike the CB-prefixed forms, the accumulator rotates always clear the Zero flag.
+func rotateA(rotate func(val *uint8, f *Flags), a *uint8, f *Flags) {
+	rotate(a, f)
+	f.Clear(FlagZero)
+}
+
+// daa adjusts A to a valid BCD number after an addition or subtraction, using the N, H and C
+// flags left behind by that operation.
+func daa(a *uint8, f *Flags) {
+	var adjust uint8
+	carry := f.IsSet(FlagCarry)
+	if f.IsSet(FlagAddSub) {
+		if f.IsSet(FlagHalfCarry) {
+			adjust |= 0x06
+		}
+		if carry {
+			adjust |= 0x60
+		}
+		*a -= adjust
+	} else {
+		if f.IsSet(FlagHalfCarry) || *a&0x0F > 0x09 {
+			adjust |= 0x06
+		}
+		if carry || *a > 0x99 {
+			adjust |= 0x60
+			carry = true
+		}
+		*a += adjust
+	}
+	f.SetIf(*a == 0, FlagZero)
+	f.Clear(FlagHalfCarry)
+	f.SetIf(carry, FlagCarry)
+}
+
+func cpl(a *uint8, f *Flags) {
+	*a = ^*a
+	f.Set(FlagAddSub | FlagHalfCarry)
+}
+
+func scf(f *Flags) {
+	f.Clear(FlagAddSub | FlagHalfCarry)
+	f.Set(FlagCarry)
+}
+
+func ccf(f *Flags) {
+	f.Clear(FlagAddSub | FlagHalfCarry)
+	f.SetIf(!f.IsSet(FlagCarry), FlagCarry)
+}
@@ -1,50 +1,101 @@
 package cpu
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/anurse/gogb/pkg/gogb/memory"
 	"github.com/stretchr/testify/assert"
 )
 
-func createStack() (uint16, memory.RAM) {
-	var sp uint16 = 0x00FF
+// createStack returns a SM83 with a small RAM and SP pointing at the top of it.
+func createStack() (*SM83, *memory.RAM) {
 	mem := memory.NewRAM(0xFF)
-	return sp, mem
+	z := NewSM83(&mem)
+	z.State.SP = 0x00FF
+	return &z, &mem
 }
 
 // Tests for push/pop mean we can safely use them in other tests
 func TestPush(t *testing.T) {
-	sp, mem := createStack()
-	assert.NoError(t, push(0xBEEF, &sp, &mem))
-	assert.Equal(t, uint16(0xFD), sp)
+	z, mem := createStack()
+	assert.NoError(t, z.push(0xBEEF))
+	assert.Equal(t, uint16(0xFD), z.State.SP)
+	assert.Equal(t, 8, z.State.TStates)
 
-	val, err := mem.GetWord(int(sp))
+	val, err := mem.GetWord(int(z.State.SP))
 	assert.NoError(t, err)
 	assert.Equal(t, uint16(0xBEEF), val)
 }
 
-func TestPushFailsWhenOutOfStackSpace(t *testing.T) {
-	var sp uint16 = 0x00
-	mem := memory.NewRAM(0xFF)
-	assert.EqualError(t, push(0xBEEF, &sp, &mem), ErrStackOverflow.Error())
+func TestPushWritesHighByteFirst(t *testing.T) {
+	ram := memory.NewRAM(0xFF)
+	mem := memory.NewRecordingMMU(&ram)
+	z := NewSM83(mem)
+	z.State.SP = 0x00FF
+	assert.NoError(t, z.push(0xBEEF))
+	assert.Equal(t, []memory.Access{
+		{Kind: memory.AccessWrite, Addr: 0xFE, Value: 0xBE},
+		{Kind: memory.AccessWrite, Addr: 0xFD, Value: 0xEF},
+	}, mem.Accesses)
+}
+
+func TestPushFailsWhenOutOfStackSpaceInStrictMode(t *testing.T) {
+	z, _ := createStack()
+	z.StrictStack = true
+	z.State.SP = 0x00
+	assert.EqualError(t, z.push(0xBEEF), ErrStackOverflow.Error())
+}
+
+func TestPushWrapsStackPointer(t *testing.T) {
+	mem := memory.NewRAM(0x10000)
+	z := NewSM83(&mem)
+	z.State.SP = 0x0001
+	assert.NoError(t, z.push(0xBEEF))
+	assert.Equal(t, uint16(0xFFFF), z.State.SP)
+
+	hi, _ := mem.GetByte(0x0000)
+	lo, _ := mem.GetByte(0xFFFF)
+	assert.Equal(t, uint8(0xBE), hi)
+	assert.Equal(t, uint8(0xEF), lo)
+
+	val, err := z.pop()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0xBEEF), val)
+	assert.Equal(t, uint16(0x0001), z.State.SP)
 }
 
 func TestPop(t *testing.T) {
-	var sp uint16 = 0x00FD
-	mem := memory.NewRAM(0xFF)
-	assert.NoError(t, mem.SetWord(int(sp), 0xBEEF))
+	z, mem := createStack()
+	z.State.SP = 0x00FD
+	assert.NoError(t, mem.SetWord(int(z.State.SP), 0xBEEF))
 
-	val, err := pop(&sp, &mem)
+	val, err := z.pop()
 	assert.NoError(t, err)
 	assert.Equal(t, uint16(0xBEEF), val)
+	assert.Equal(t, uint16(0x00FF), z.State.SP)
+	assert.Equal(t, 8, z.State.TStates)
 }
 
-func TestPopFailsWhenStackUnderflows(t *testing.T) {
-	sp, mem := createStack()
-	res, err := pop(&sp, &mem)
+func TestPopFailsWhenStackUnderflowsInStrictMode(t *testing.T) {
+	z, _ := createStack()
+	z.StrictStack = true
+	res, err := z.pop()
 	assert.EqualError(t, err, ErrStackUnderflow.Error())
 	assert.Equal(t, uint16(0), res)
+	assert.Equal(t, uint16(0x00FF), z.State.SP)
+
+	mem := memory.NewRAM(0x10000)
+	z = &SM83{Memory: &mem, StrictStack: true}
+	z.State.SP = 0xFFFF
+	_, err = z.pop()
+	assert.EqualError(t, err, ErrStackUnderflow.Error())
+}
+
+func TestPopReportsBusErrorOutsideStrictMode(t *testing.T) {
+	z, _ := createStack()
+	_, err := z.pop()
+	assert.True(t, errors.Is(err, memory.ErrAddressOutOfRange))
 }
 
 // Arithmetic operations
@@ -110,6 +161,147 @@ func TestAdd8ClearsHalfCarryIfLowNybbleDoesNotOverflow(t *testing.T) {
 	assert.True(t, f.IsClear(FlagHalfCarry))
 }
 
+func TestSub8SubtractsOperandFromA(t *testing.T) {
+	var a uint8 = 44
+	f := FlagEmpty
+	sub8(&a, 2, &f, false)
+	assert.Equal(t, uint8(42), a)
+	assert.True(t, f.IsSet(FlagAddSub))
+}
+
+func TestSbcBorrowsIn(t *testing.T) {
+	var a uint8 = 0x03
+	f := FlagCarry
+	sub8(&a, 1, &f, true)
+	assert.Equal(t, uint8(1), a)
+}
+
+func TestSub8SetsZeroFlagIfResultIsZero(t *testing.T) {
+	var a uint8 = 0x42
+	f := FlagEmpty
+	sub8(&a, 0x42, &f, false)
+	assert.Equal(t, uint8(0), a)
+	assert.True(t, f.IsSet(FlagZero))
+}
+
+func TestSub8ClearsZeroFlagIfResultIsNonZero(t *testing.T) {
+	var a uint8 = 44
+	f := FlagZero
+	sub8(&a, 2, &f, false)
+	assert.True(t, f.IsClear(FlagZero))
+}
+
+func TestSub8SetsCarryIfResultBorrows(t *testing.T) {
+	var a uint8 = 0x01
+	f := FlagEmpty
+	sub8(&a, 0x02, &f, false)
+	assert.Equal(t, uint8(0xFF), a)
+	assert.True(t, f.IsSet(FlagCarry))
+}
+
+func TestSub8ClearsCarryIfResultDoesNotBorrow(t *testing.T) {
+	var a uint8 = 0x02
+	f := FlagCarry
+	sub8(&a, 0x01, &f, false)
+	assert.True(t, f.IsClear(FlagCarry))
+}
+
+func TestSub8SetsHalfCarryIfLowNybbleBorrows(t *testing.T) {
+	var a uint8 = 0x10
+	f := FlagEmpty
+	sub8(&a, 0x01, &f, false)
+	assert.Equal(t, uint8(0x0F), a)
+	assert.True(t, f.IsSet(FlagHalfCarry))
+}
+
+func TestSub8ClearsHalfCarryIfLowNybbleDoesNotBorrow(t *testing.T) {
+	var a uint8 = 0x1F
+	f := FlagHalfCarry
+	sub8(&a, 0x01, &f, false)
+	assert.True(t, f.IsClear(FlagHalfCarry))
+}
+
+func TestSbcCarryInCanCauseHalfBorrow(t *testing.T) {
+	var a uint8 = 0x10
+	f := FlagCarry
+	sub8(&a, 0x00, &f, true)
+	assert.Equal(t, uint8(0x0F), a)
+	assert.True(t, f.IsSet(FlagHalfCarry))
+	assert.True(t, f.IsClear(FlagCarry))
+}
+
+func TestCpSetsFlagsWithoutModifyingA(t *testing.T) {
+	var a uint8 = 0x42
+	f := FlagEmpty
+	cp(a, 0x42, &f)
+	assert.Equal(t, uint8(0x42), a)
+	assert.Equal(t, FlagZero|FlagAddSub, f)
+
+	cp(a, 0x43, &f)
+	assert.Equal(t, FlagAddSub|FlagHalfCarry|FlagCarry, f)
+}
+
+func TestInc8SetsHalfCarryWhenLowNybbleOverflows(t *testing.T) {
+	var val uint8 = 0x0F
+	f := FlagAddSub
+	inc8(&val, &f)
+	assert.Equal(t, uint8(0x10), val)
+	assert.Equal(t, FlagHalfCarry, f)
+}
+
+func TestInc8ClearsHalfCarryWhenLowNybbleDoesNotOverflow(t *testing.T) {
+	var val uint8 = 0x10
+	f := FlagHalfCarry
+	inc8(&val, &f)
+	assert.Equal(t, uint8(0x11), val)
+	assert.Equal(t, FlagEmpty, f)
+}
+
+func TestInc8WrapsToZeroWithoutTouchingCarry(t *testing.T) {
+	var val uint8 = 0xFF
+	f := FlagEmpty
+	inc8(&val, &f)
+	assert.Equal(t, uint8(0), val)
+	assert.Equal(t, FlagZero|FlagHalfCarry, f)
+
+	f = FlagCarry
+	val = 0x00
+	inc8(&val, &f)
+	assert.Equal(t, FlagCarry, f)
+}
+
+func TestDec8SetsHalfCarryWhenLowNybbleBorrows(t *testing.T) {
+	var val uint8 = 0x10
+	f := FlagEmpty
+	dec8(&val, &f)
+	assert.Equal(t, uint8(0x0F), val)
+	assert.Equal(t, FlagHalfCarry|FlagAddSub, f)
+}
+
+func TestDec8ClearsHalfCarryWhenLowNybbleDoesNotBorrow(t *testing.T) {
+	var val uint8 = 0x0F
+	f := FlagHalfCarry
+	dec8(&val, &f)
+	assert.Equal(t, uint8(0x0E), val)
+	assert.Equal(t, FlagAddSub, f)
+}
+
+func TestDec8SetsZeroWithoutTouchingCarry(t *testing.T) {
+	var val uint8 = 0x01
+	f := FlagCarry
+	dec8(&val, &f)
+	assert.Equal(t, uint8(0), val)
+	assert.Equal(t, FlagZero|FlagAddSub|FlagCarry, f)
+}
+
+func TestInc16AndDec16Wrap(t *testing.T) {
+	var val uint16 = 0xFFFF
+	inc16(&val)
+	assert.Equal(t, uint16(0), val)
+	dec16(&val)
+	assert.Equal(t, uint16(0xFFFF), val)
+}
+
 func TestAdd16AddsValues(t *testing.T) {
 	var hl uint16 = 0xAA00
 	var sp uint16 = 0x00AA
@@ -173,6 +365,37 @@ func TestAndSetsZeroFlag(t *testing.T) {
 	assert.True(t, f.IsSet(FlagZero))
 }
 
+func TestOr(t *testing.T) {
+	var a uint8 = 0b1010_0000
+	f := FlagAddSub | FlagCarry | FlagHalfCarry | FlagZero
+	or(&a, 0b0000_0101, &f)
+	assert.Equal(t, uint8(0b1010_0101), a)
+	assert.Equal(t, FlagEmpty, f)
+}
+
+func TestOrSetsZeroFlag(t *testing.T) {
+	var a uint8 = 0
+	f := FlagEmpty
+	or(&a, 0, &f)
+	assert.Equal(t, FlagZero, f)
+}
+
+func TestXor(t *testing.T) {
+	var a uint8 = 0b1010_1010
+	f := FlagAddSub | FlagCarry | FlagHalfCarry
+	xor(&a, 0b1111_0000, &f)
+	assert.Equal(t, uint8(0b0101_1010), a)
+	assert.Equal(t, FlagEmpty, f)
+}
+
+func TestXorASetsZeroAndClearsCarries(t *testing.T) {
+	var a uint8 = 0x42
+	f := FlagCarry | FlagHalfCarry | FlagAddSub
+	xor(&a, a, &f)
+	assert.Equal(t, uint8(0), a)
+	assert.Equal(t, FlagZero, f)
+}
+
 func TestBitSetsZeroFlagIfBitIsNotSet(t *testing.T) {
 	f := FlagEmpty
 	bit(6, 0b1011_1111, &f)
@@ -187,17 +410,157 @@ func TestBitClearsZeroFlagIfBitIsSet(t *testing.T) {
 
 // Call/Jump Operations
 func TestCallPushesPCValueToStack(t *testing.T) {
-	sp, mem := createStack()
-	var pc uint16 = 0xFEED
-	assert.NoError(t, call(0xBEEF, &pc, &sp, &mem))
-	res, err := pop(&sp, &mem)
+	z, _ := createStack()
+	z.State.PC = 0xFEED
+	assert.NoError(t, z.call(0xBEEF))
+	res, err := z.pop()
 	assert.NoError(t, err)
 	assert.Equal(t, uint16(0xFEED), res)
 }
 
 func TestCallSetsPCToSpecifiedValue(t *testing.T) {
-	sp, mem := createStack()
-	var pc uint16 = 0xFEED
-	assert.NoError(t, call(0xBEEF, &pc, &sp, &mem))
-	assert.Equal(t, uint16(0xBEEF), pc)
+	z, _ := createStack()
+	z.State.PC = 0xFEED
+	assert.NoError(t, z.call(0xBEEF))
+	assert.Equal(t, uint16(0xBEEF), z.State.PC)
+	assert.Equal(t, 12, z.State.TStates)
+}
+
+func TestBitSetsHalfCarryAndClearsAddSub(t *testing.T) {
+	f := FlagAddSub | FlagCarry
+	bit(0, 0x01, &f)
+	assert.Equal(t, FlagHalfCarry|FlagCarry, f)
+}
+
+// Rotate, shift and bit manipulation
+func TestRlcRotatesHighBitIntoCarryAndBitZero(t *testing.T) {
+	var val uint8 = 0b1000_0101
+	f := FlagEmpty
+	rlc(&val, &f)
+	assert.Equal(t, uint8(0b0000_1011), val)
+	assert.Equal(t, FlagCarry, f)
+}
+
+func TestRrcRotatesLowBitIntoCarryAndBitSeven(t *testing.T) {
+	var val uint8 = 0b0000_0001
+	f := FlagEmpty
+	rrc(&val, &f)
+	assert.Equal(t, uint8(0b1000_0000), val)
+	assert.Equal(t, FlagCarry, f)
+}
+
+func TestRlRotatesThroughCarry(t *testing.T) {
+	var val uint8 = 0b1000_0000
+	f := FlagEmpty
+	rl(&val, &f)
+	assert.Equal(t, uint8(0), val)
+	assert.Equal(t, FlagZero|FlagCarry, f)
+
+	rl(&val, &f)
+	assert.Equal(t, uint8(1), val)
+	assert.Equal(t, FlagEmpty, f)
+}
+
+func TestRrRotatesThroughCarry(t *testing.T) {
+	var val uint8 = 0b0000_0010
+	f := FlagCarry
+	rr(&val, &f)
+	assert.Equal(t, uint8(0b1000_0001), val)
+	assert.Equal(t, FlagEmpty, f)
+}
+
+func TestSlaShiftsInZero(t *testing.T) {
+	var val uint8 = 0b1111_1111
+	f := FlagCarry
+	sla(&val, &f)
+	assert.Equal(t, uint8(0b1111_1110), val)
+	assert.Equal(t, FlagCarry, f)
+}
+
+func TestSraPreservesSignBit(t *testing.T) {
+	var val uint8 = 0b1000_0001
+	f := FlagEmpty
+	sra(&val, &f)
+	assert.Equal(t, uint8(0b1100_0000), val)
+	assert.Equal(t, FlagCarry, f)
+}
+
+func TestSrlShiftsInZero(t *testing.T) {
+	var val uint8 = 0b0000_0001
+	f := FlagEmpty
+	srl(&val, &f)
+	assert.Equal(t, uint8(0), val)
+	assert.Equal(t, FlagZero|FlagCarry, f)
+}
+
+func TestSwapExchangesNybblesAndClearsCarry(t *testing.T) {
+	var val uint8 = 0xA5
+	f := FlagCarry | FlagHalfCarry | FlagAddSub
+	swap(&val, &f)
+	assert.Equal(t, uint8(0x5A), val)
+	assert.Equal(t, FlagEmpty, f)
+}
+
+func TestResAndSet(t *testing.T) {
+	var val uint8 = 0xFF
+	res(3, &val)
+	assert.Equal(t, uint8(0xF7), val)
+	set(3, &val)
+	assert.Equal(t, uint8(0xFF), val)
+}
+
+func TestRotateAClearsZeroEvenIfResultIsZero(t *testing.T) {
+	var a uint8 = 0x80
+	f := FlagZero
+	rotateA(rl, &a, &f)
+	assert.Equal(t, uint8(0), a)
+	assert.Equal(t, FlagCarry, f)
+}
+
+func TestDaaAdjustsToBCD(t *testing.T) {
+	tests := []struct {
+		a        uint8
+		flags    Flags
+		expected uint8
+		result   Flags
+	}{
+		{0x0A, FlagEmpty, 0x10, FlagEmpty},
+		{0x9A, FlagEmpty, 0x00, FlagZero | FlagCarry},
+		{0x12, FlagHalfCarry, 0x18, FlagEmpty},
+		{0x12, FlagCarry, 0x72, FlagCarry},
+		{0xA0, FlagEmpty, 0x00, FlagZero | FlagCarry},
+		{0x0F, FlagAddSub | FlagHalfCarry, 0x09, FlagAddSub},
+		{0xF0, FlagAddSub | FlagCarry, 0x90, FlagAddSub | FlagCarry},
+		{0x00, FlagAddSub | FlagHalfCarry | FlagCarry, 0x9A, FlagAddSub | FlagCarry},
+		{0x99, FlagZero, 0x99, FlagEmpty},
+	}
+	for _, tt := range tests {
+		a, f := tt.a, tt.flags
+		daa(&a, &f)
+		assert.Equal(t, tt.expected, a, "A=%02X F=%s", tt.a, tt.flags)
+		assert.Equal(t, tt.result, f, "A=%02X F=%s", tt.a, tt.flags)
+	}
+}
+
+// SP offset arithmetic
+func TestAddSPUsesLowByteForFlags(t *testing.T) {
+	tests := []struct {
+		sp       uint16
+		offset   uint8
+		expected uint16
+		flags    Flags
+	}{
+		{0xFFFF, 0x01, 0x0000, FlagHalfCarry | FlagCarry}, // Z is never set, even for a zero result
+		{0x0000, 0xFF, 0xFFFF, FlagEmpty},                 // -1 with no carries out of the low byte
+		{0x00FF, 0x01, 0x0100, FlagHalfCarry | FlagCarry},
+		{0x000F, 0x01, 0x0010, FlagHalfCarry},
+		{0x00F0, 0x10, 0x0100, FlagCarry},
+		{0x1000, 0x80, 0x0F80, FlagEmpty}, // -128
+		{0x0001, 0xFF, 0x0000, FlagHalfCarry | FlagCarry},
+	}
+	for _, tt := range tests {
+		f := FlagZero | FlagAddSub
+		assert.Equal(t, tt.expected, addSP(tt.sp, tt.offset, &f), "SP=%04X e8=%02X", tt.sp, tt.offset)
+		assert.Equal(t, tt.flags, f, "SP=%04X e8=%02X", tt.sp, tt.offset)
+	}
 }
@@ -0,0 +1,122 @@
+package cpu
+
+import "fmt"
+
+// An Interrupt identifies one of the interrupt sources by its bit in the IE and IF registers.
+type Interrupt uint8
+
+// The interrupt sources, in priority order (VBlank is serviced first)
+const (
+	InterruptVBlank Interrupt = 1 << iota
+	InterruptSTAT
+	InterruptTimer
+	InterruptSerial
+	InterruptJoypad
+)
+
+// interruptMask covers the IE/IF bits that correspond to an interrupt source.
+const interruptMask = 0x1F
+
+// Vector returns the address the CPU jumps to when servicing the interrupt.
+func (i Interrupt) Vector() uint16 {
+	switch i {
+	case InterruptVBlank:
+		return 0x0040
+	case InterruptSTAT:
+		return 0x0048
+	case InterruptTimer:
+		return 0x0050
+	case InterruptSerial:
+		return 0x0058
+	default:
+		return 0x0060
+	}
+}
+
+func (i Interrupt) String() string {
+	switch i {
+	case InterruptVBlank:
+		return "VBlank"
+	case InterruptSTAT:
+		return "STAT"
+	case InterruptTimer:
+		return "Timer"
+	case InterruptSerial:
+		return "Serial"
+	case InterruptJoypad:
+		return "Joypad"
+	default:
+		return fmt.Sprintf("Unknown(0x%02X)", uint8(i))
+	}
+}
+
+// RequestInterrupt sets the interrupt's bit in IF. It is serviced once it is enabled in IE and IME is set.
+func (z *SM83) RequestInterrupt(i Interrupt) error {
+	val, err := z.Memory.GetByte(addrIF)
+	if err != nil {
+		return err
+	}
+	return z.Memory.SetByte(addrIF, val|uint8(i))
+}
+
+// pendingInterrupt returns the highest priority interrupt that is both requested and enabled,
+// or 0 if there is none.
+func (z *SM83) pendingInterrupt() (Interrupt, error) {
+	flags, err := z.Memory.GetByte(addrIF)
+	if err != nil {
+		return 0, err
+	}
+	enabled, err := z.Memory.GetByte(addrIE)
+	if err != nil {
+		return 0, err
+	}
+	pending := flags & enabled & interruptMask
+
+	// Isolate the lowest set bit, which is the highest priority
+	return Interrupt(pending & -pending), nil
+}
+
+// dispatch services the highest priority pending interrupt: IME is cleared, PC is pushed and the CPU
+// jumps to the interrupt vector, clearing the interrupt's IF bit. The whole sequence takes 5 M-cycles.
+//
+// The interrupt is only chosen after the high byte of PC has been pushed. If SP was 0x0000, that write
+// lands on IE and may disable every pending interrupt, in which case the dispatch is canceled: no IF bit
+// is cleared, the CPU continues at 0x0000 and dispatch returns 0.
+func (z *SM83) dispatch() (Interrupt, error) {
+	z.State.IME = false
+	z.State.Halted = false
+	z.State.Stopped = false
+
+	// Two internal M-cycles, the push, then one more to load the vector into PC
+	z.idle()
+	z.idle()
+	if z.StrictStack && z.State.SP < 2 {
+		return 0, ErrStackOverflow
+	}
+	pc := z.State.PC
+	if err := z.pushByte(uint8(pc >> 8)); err != nil {
+		return 0, err
+	}
+	i, err := z.pendingInterrupt()
+	if err != nil {
+		return 0, err
+	}
+	if err := z.pushByte(uint8(pc)); err != nil {
+		return 0, err
+	}
+
+	if i == 0 {
+		z.State.PC = 0x0000
+	} else {
+		flags, err := z.Memory.GetByte(addrIF)
+		if err != nil {
+			return 0, err
+		}
+		if err := z.Memory.SetByte(addrIF, flags&^uint8(i)); err != nil {
+			return 0, err
+		}
+		z.State.PC = i.Vector()
+	}
+	z.idle()
+	return i, nil
+}
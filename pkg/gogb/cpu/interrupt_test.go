@@ -0,0 +1,235 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterruptVectors(t *testing.T) {
+	assert.Equal(t, uint16(0x0040), InterruptVBlank.Vector())
+	assert.Equal(t, uint16(0x0048), InterruptSTAT.Vector())
+	assert.Equal(t, uint16(0x0050), InterruptTimer.Vector())
+	assert.Equal(t, uint16(0x0058), InterruptSerial.Vector())
+	assert.Equal(t, uint16(0x0060), InterruptJoypad.Vector())
+}
+
+func TestStepDispatchesInterrupt(t *testing.T) {
+	z, mem := createCPU()
+	z.State.PC = 0x0200
+	z.State.SP = 0xFFFE
+	z.State.IME = true
+	mem.SetByte(addrIE, uint8(InterruptTimer))
+	assert.NoError(t, z.RequestInterrupt(InterruptTimer))
+
+	step(t, z)
+	assert.Equal(t, uint16(0x0050), z.State.PC)
+	assert.Equal(t, uint16(0xFFFC), z.State.SP)
+	assert.False(t, z.State.IME)
+	assert.Equal(t, 20, z.State.TStates)
+
+	ret, _ := mem.GetWord(0xFFFC)
+	assert.Equal(t, uint16(0x0200), ret)
+	flags, _ := mem.GetByte(addrIF)
+	assert.Equal(t, uint8(0x00), flags)
+}
+
+func TestStepDispatchesHighestPriorityInterruptFirst(t *testing.T) {
+	z, mem := createCPU()
+	z.State.SP = 0xFFFE
+	z.State.IME = true
+	mem.SetByte(addrIE, 0x1F)
+	mem.SetByte(addrIF, uint8(InterruptJoypad|InterruptSTAT|InterruptSerial))
+
+	step(t, z)
+	assert.Equal(t, uint16(0x0048), z.State.PC)
+	flags, _ := mem.GetByte(addrIF)
+	assert.Equal(t, uint8(InterruptJoypad|InterruptSerial), flags)
+}
+
+func TestStepIgnoresDisabledOrMaskedInterrupts(t *testing.T) {
+	z, mem := createCPU(0x00, 0x00) // NOP; NOP
+	mem.SetByte(addrIF, uint8(InterruptVBlank))
+
+	// Requested but not enabled in IE
+	z.State.IME = true
+	step(t, z)
+	assert.Equal(t, uint16(0x0001), z.State.PC)
+
+	// Enabled in IE but IME is clear
+	z.State.IME = false
+	mem.SetByte(addrIE, uint8(InterruptVBlank))
+	step(t, z)
+	assert.Equal(t, uint16(0x0002), z.State.PC)
+}
+
+func TestEIEnablesInterruptsAfterTheNextInstruction(t *testing.T) {
+	z, mem := createCPU(0xFB, 0x00, 0x00) // EI; NOP; NOP
+	z.State.SP = 0xFFFE
+	mem.SetByte(addrIE, uint8(InterruptVBlank))
+	mem.SetByte(addrIF, uint8(InterruptVBlank))
+
+	step(t, z)
+	assert.False(t, z.State.IME)
+	step(t, z)
+	assert.True(t, z.State.IME)
+	assert.Equal(t, uint16(0x0002), z.State.PC)
+
+	step(t, z)
+	assert.Equal(t, uint16(0x0040), z.State.PC)
+}
+
+func TestDIImmediatelyAfterEIKeepsInterruptsDisabled(t *testing.T) {
+	z, _ := createCPU(0xFB, 0xF3, 0x00) // EI; DI; NOP
+	for i := 0; i < 3; i++ {
+		step(t, z)
+	}
+	assert.False(t, z.State.IME)
+	assert.False(t, z.State.IMEPending)
+}
+
+func TestHaltWaitsForInterrupt(t *testing.T) {
+	z, mem := createCPU(0x76, 0x00) // HALT; NOP
+	z.State.SP = 0xFFFE
+	z.State.IME = true
+	mem.SetByte(addrIE, uint8(InterruptSerial))
+
+	step(t, z)
+	assert.True(t, z.State.Halted)
+	step(t, z)
+	assert.Equal(t, uint16(0x0001), z.State.PC)
+	assert.Equal(t, 8, z.State.TStates)
+
+	assert.NoError(t, z.RequestInterrupt(InterruptSerial))
+	step(t, z)
+	assert.False(t, z.State.Halted)
+	assert.Equal(t, uint16(0x0058), z.State.PC)
+	ret, _ := mem.GetWord(0xFFFC)
+	assert.Equal(t, uint16(0x0001), ret)
+}
+
+func TestStepReturnsDispatchCycles(t *testing.T) {
+	z, mem := createCPU()
+	z.State.SP = 0xFFFE
+	z.State.IME = true
+	mem.SetByte(addrIE, uint8(InterruptVBlank))
+	mem.SetByte(addrIF, uint8(InterruptVBlank))
+	assert.Equal(t, 20, step(t, z))
+}
+
+func TestDispatchIsCanceledWhenPushOverwritesIE(t *testing.T) {
+	z, mem := createCPU()
+	z.State.PC = 0x0200
+	z.State.SP = 0x0000
+	z.State.IME = true
+	mem.SetByte(addrIE, uint8(InterruptVBlank))
+	mem.SetByte(addrIF, uint8(InterruptVBlank))
+
+	// The high byte of PC (0x02) lands on IE and disables VBlank
+	assert.Equal(t, 20, step(t, z))
+	assert.Equal(t, uint16(0x0000), z.State.PC)
+	assert.Equal(t, uint16(0xFFFE), z.State.SP)
+	assert.False(t, z.State.IME)
+
+	ie, _ := mem.GetByte(addrIE)
+	assert.Equal(t, uint8(0x02), ie)
+	flags, _ := mem.GetByte(addrIF)
+	assert.Equal(t, uint8(InterruptVBlank), flags)
+}
+
+func TestDispatchPicksInterruptEnabledByPushOverwritingIE(t *testing.T) {
+	z, mem := createCPU()
+	z.State.PC = 0x0200
+	z.State.SP = 0x0000
+	z.State.IME = true
+	mem.SetByte(addrIE, uint8(InterruptVBlank))
+	mem.SetByte(addrIF, uint8(InterruptVBlank|InterruptSTAT))
+
+	// IE becomes 0x02, so STAT is serviced instead of VBlank
+	step(t, z)
+	assert.Equal(t, uint16(0x0048), z.State.PC)
+	flags, _ := mem.GetByte(addrIF)
+	assert.Equal(t, uint8(InterruptVBlank), flags)
+}
+
+func TestHaltWakesWithoutServicingWhenIMEIsClear(t *testing.T) {
+	z, mem := createCPU(0x76, 0x3C) // HALT; INC A
+	mem.SetByte(addrIE, uint8(InterruptTimer))
+
+	step(t, z)
+	step(t, z)
+	assert.True(t, z.State.Halted)
+
+	assert.NoError(t, z.RequestInterrupt(InterruptTimer))
+	step(t, z)
+	assert.False(t, z.State.Halted)
+	assert.Equal(t, uint16(0x0001), z.State.PC)
+
+	// Execution continues after the HALT and the interrupt stays pending
+	step(t, z)
+	assert.Equal(t, uint8(0x01), z.State.A)
+	assert.Equal(t, uint16(0x0002), z.State.PC)
+	flags, _ := mem.GetByte(addrIF)
+	assert.Equal(t, uint8(InterruptTimer), flags)
+}
+
+func TestHaltStaysHaltedForDisabledInterrupts(t *testing.T) {
+	z, mem := createCPU(0x76) // HALT
+	mem.SetByte(addrIE, uint8(InterruptVBlank))
+	step(t, z)
+	assert.NoError(t, z.RequestInterrupt(InterruptTimer))
+	step(t, z)
+	assert.True(t, z.State.Halted)
+}
+
+func TestInterruptResumesExecutionAfterStop(t *testing.T) {
+	z, mem := createCPU(0x10, 0x00, 0x00) // STOP; NOP
+	z.State.SP = 0xFFFE
+	z.State.IME = true
+	mem.SetByte(addrIE, uint8(InterruptJoypad))
+
+	step(t, z)
+	step(t, z)
+	assert.True(t, z.State.Stopped)
+	assert.Equal(t, uint16(0x0002), z.State.PC)
+
+	assert.NoError(t, z.RequestInterrupt(InterruptJoypad))
+	step(t, z)
+	assert.False(t, z.State.Stopped)
+	assert.Equal(t, uint16(0x0060), z.State.PC)
+	ret, _ := mem.GetWord(0xFFFC)
+	assert.Equal(t, uint16(0x0002), ret)
+}
+
+func TestStopWakesWithoutServicingWhenIMEIsClear(t *testing.T) {
+	z, mem := createCPU(0x10, 0x00, 0x3C) // STOP; INC A
+	mem.SetByte(addrIE, uint8(InterruptJoypad))
+
+	step(t, z)
+	assert.True(t, z.State.Stopped)
+
+	assert.NoError(t, z.RequestInterrupt(InterruptJoypad))
+	step(t, z)
+	assert.False(t, z.State.Stopped)
+	step(t, z)
+	assert.Equal(t, uint8(0x01), z.State.A)
+	assert.Equal(t, uint16(0x0003), z.State.PC)
+}
+
+func TestHaltBugReadsNextByteTwice(t *testing.T) {
+	z, mem := createCPU(0x76, 0x3C, 0x00) // HALT; INC A; NOP
+	mem.SetByte(addrIE, uint8(InterruptTimer))
+	mem.SetByte(addrIF, uint8(InterruptTimer))
+
+	step(t, z)
+	assert.False(t, z.State.Halted)
+	assert.True(t, z.State.HaltBug)
+	assert.Equal(t, uint16(0x0001), z.State.PC)
+
+	// INC A is executed twice
+	step(t, z)
+	assert.Equal(t, uint16(0x0001), z.State.PC)
+	step(t, z)
+	assert.Equal(t, uint16(0x0002), z.State.PC)
+	assert.Equal(t, uint8(0x02), z.State.A)
+}
@@ -0,0 +1,61 @@
+package cpu
+
+import "github.com/anurse/gogb/pkg/gogb/memory"
+
+// A KEY1 is the CGB speed switch register (0xFF4D). Bit 0 arms the switch, which the next STOP performs;
+// bit 7 reports the current speed, read from the CPU's State.DoubleSpeed so that the register can't
+// disagree with it. The other bits read as 1 and only bit 0 can be written.
+//
+// On a CGB it must be mapped as the owner of memory.IOSpeed.
+type KEY1 struct {
+	cpu   *SM83
+	armed bool
+}
+
+// NewKEY1 creates the speed switch register of the specified CPU, disarmed.
+func NewKEY1(z *SM83) *KEY1 {
+	return &KEY1{cpu: z}
+}
+
+// GetByte reads KEY1. Returns memory.ErrAddressOutOfRange for any other address.
+func (k *KEY1) GetByte(addr int) (uint8, error) {
+	if addr != addrKEY1 {
+		return 0, memory.ErrAddressOutOfRange
+	}
+	val := uint8(0x7E)
+	if k.cpu.State.DoubleSpeed {
+		val |= 0x80
+	}
+	if k.armed {
+		val |= 0x01
+	}
+	return val, nil
+}
+
+// GetWord reads a little-endian word as two byte reads.
+func (k *KEY1) GetWord(addr int) (uint16, error) {
+	lo, err := k.GetByte(addr)
+	if err != nil {
+		return 0, err
+	}
+	hi, err := k.GetByte(addr + 1)
+	return uint16(lo) | uint16(hi)<<8, err
+}
+
+// SetByte arms or disarms the switch through bit 0; the other bits are ignored.
+// Returns memory.ErrAddressOutOfRange for any other address.
+func (k *KEY1) SetByte(addr int, val uint8) error {
+	if addr != addrKEY1 {
+		return memory.ErrAddressOutOfRange
+	}
+	k.armed = val&0x01 != 0
+	return nil
+}
+
+// SetWord writes a little-endian word as two byte writes.
+func (k *KEY1) SetWord(addr int, val uint16) error {
+	if err := k.SetByte(addr, uint8(val)); err != nil {
+		return err
+	}
+	return k.SetByte(addr+1, uint8(val>>8))
+}
@@ -0,0 +1,135 @@
+package cpu
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrStateInvalid occurs when unmarshaling data that was not produced by MarshalBinary,
+// or was produced by an incompatible version.
+var ErrStateInvalid error = errors.New("invalid cpu state data")
+
+// The version written at the start of serialized states. Bump it whenever the layout changes.
+const stateVersion = 1
+
+// The serialized size of a State and a SM83.
+const (
+	stateSize = 22
+	sm83Size  = stateSize + 1
+)
+
+// Bits of the status byte of a serialized State, as laid out in version 1
+const (
+	statusIME = 1 << iota
+	statusIMEPending
+	statusHalted
+	statusStopped
+	statusLocked
+	statusDoubleSpeed
+	statusHaltBug
+)
+
+// Bits of the configuration byte of a serialized SM83
+const (
+	configCGB = 1 << iota
+	configStrictStack
+)
+
+// MarshalBinary encodes the registers, clock and execution status (IME, pending EI, HALT and the
+// HALT bug, STOP, lock-up and CPU speed) into a fixed-size little-endian layout, for save states.
+func (s State) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 0, stateSize)
+	out = append(out, stateVersion, s.A, uint8(s.F), s.B, s.C, s.D, s.E, s.H, s.L)
+	out = binary.LittleEndian.AppendUint16(out, s.SP)
+	out = binary.LittleEndian.AppendUint16(out, s.PC)
+	out = binary.LittleEndian.AppendUint64(out, uint64(s.TStates))
+
+	var status uint8
+	if s.IME {
+		status |= statusIME
+	}
+	if s.IMEPending {
+		status |= statusIMEPending
+	}
+	if s.Halted {
+		status |= statusHalted
+	}
+	if s.Stopped {
+		status |= statusStopped
+	}
+	if s.Locked {
+		status |= statusLocked
+	}
+	if s.DoubleSpeed {
+		status |= statusDoubleSpeed
+	}
+	if s.HaltBug {
+		status |= statusHaltBug
+	}
+	return append(out, status), nil
+}
+
+// UnmarshalBinary restores a State encoded by MarshalBinary.
+// Returns ErrStateInvalid if the data has the wrong size or version.
+func (s *State) UnmarshalBinary(data []byte) error {
+	if len(data) != stateSize || data[0] != stateVersion {
+		return ErrStateInvalid
+	}
+	*s = State{
+		A: data[1],
+		// The low nibble of F doesn't exist on hardware
+		F:       Flags(data[2]) & 0xF0,
+		B:       data[3],
+		C:       data[4],
+		D:       data[5],
+		E:       data[6],
+		H:       data[7],
+		L:       data[8],
+		SP:      binary.LittleEndian.Uint16(data[9:]),
+		PC:      binary.LittleEndian.Uint16(data[11:]),
+		TStates: int(binary.LittleEndian.Uint64(data[13:])),
+	}
+
+	status := data[21]
+	s.IME = status&statusIME != 0
+	s.IMEPending = status&statusIMEPending != 0
+	s.Halted = status&statusHalted != 0
+	s.Stopped = status&statusStopped != 0
+	s.Locked = status&statusLocked != 0
+	s.DoubleSpeed = status&statusDoubleSpeed != 0
+	s.HaltBug = status&statusHaltBug != 0
+	return nil
+}
+
+// MarshalBinary encodes the CPU state and configuration. The memory is not included;
+// it has to be saved separately.
+func (z *SM83) MarshalBinary() ([]byte, error) {
+	out, err := z.State.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	var config uint8
+	if z.CGB {
+		config |= configCGB
+	}
+	if z.StrictStack {
+		config |= configStrictStack
+	}
+	return append(out, config), nil
+}
+
+// UnmarshalBinary restores the CPU state and configuration encoded by MarshalBinary.
+// The memory is left unchanged. Returns ErrStateInvalid if the data has the wrong size or version.
+func (z *SM83) UnmarshalBinary(data []byte) error {
+	if len(data) != sm83Size {
+		return ErrStateInvalid
+	}
+	if err := z.State.UnmarshalBinary(data[:stateSize]); err != nil {
+		return err
+	}
+	config := data[stateSize]
+	z.CGB = config&configCGB != 0
+	z.StrictStack = config&configStrictStack != 0
+	return nil
+}
@@ -0,0 +1,67 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateRoundTrips(t *testing.T) {
+	s := State{
+		A: 0x01, F: FlagZero | FlagCarry, B: 0x02, C: 0x03, D: 0x04, E: 0x05, H: 0x06, L: 0x07,
+		SP: 0xFFFE, PC: 0x0150, TStates: 1 << 40,
+		IME: true, Halted: true, DoubleSpeed: true,
+	}
+	data, err := s.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Len(t, data, stateSize)
+
+	var out State
+	assert.NoError(t, out.UnmarshalBinary(data))
+	assert.Equal(t, s, out)
+}
+
+func TestStateRoundTripsExecutionStatus(t *testing.T) {
+	for _, s := range []State{{IMEPending: true}, {Stopped: true}, {Locked: true}, {HaltBug: true}} {
+		data, _ := s.MarshalBinary()
+		var out State
+		assert.NoError(t, out.UnmarshalBinary(data))
+		assert.Equal(t, s, out)
+	}
+}
+
+func TestStateUnmarshalRejectsInvalidData(t *testing.T) {
+	data, _ := State{}.MarshalBinary()
+	var s State
+	assert.Equal(t, ErrStateInvalid, s.UnmarshalBinary(data[:stateSize-1]))
+
+	data[0] = stateVersion + 1
+	assert.Equal(t, ErrStateInvalid, s.UnmarshalBinary(data))
+}
+
+func TestStateUnmarshalMasksLowNibbleOfF(t *testing.T) {
+	data, _ := State{}.MarshalBinary()
+	data[2] = 0xFF
+	var s State
+	assert.NoError(t, s.UnmarshalBinary(data))
+	assert.Equal(t, Flags(0xF0), s.F)
+}
+
+func TestZ80RoundTripsWithoutTouchingMemory(t *testing.T) {
+	mem := memory.NewRAM(0x10)
+	z := NewSM83(&mem)
+	z.CGB = true
+	z.State.PC = 0x1234
+	data, err := z.MarshalBinary()
+	assert.NoError(t, err)
+
+	other := memory.NewRAM(0x10)
+	restored := NewSM83(&other)
+	restored.StrictStack = true
+	assert.NoError(t, restored.UnmarshalBinary(data))
+	assert.Equal(t, z.State, restored.State)
+	assert.True(t, restored.CGB)
+	assert.False(t, restored.StrictStack)
+	assert.Same(t, &other, restored.Memory)
+}
@@ -0,0 +1,21 @@
+package cpu
+
+// An OAMBugTrigger emulates the DMG OAM corruption bug. The CPU calls it whenever its 16-bit
+// increment/decrement unit operates on a value in 0xFE00-0xFEFF, which puts that address on the
+// bus. The implementation (normally the PPU) corrupts OAM if it is currently in mode 2.
+type OAMBugTrigger interface {
+	TriggerOAMBug(addr uint16)
+}
+
+// Addresses that trigger the OAM bug when they pass through the increment/decrement unit
+const (
+	oamBugStart = 0xFE00
+	oamBugEnd   = 0xFEFF
+)
+
+// incDec reports a 16-bit increment or decrement of val to the OAM bug emulation, if enabled.
+func (z *SM83) incDec(val uint16) {
+	if z.OAMBug != nil && val >= oamBugStart && val <= oamBugEnd {
+		z.OAMBug.TriggerOAMBug(val)
+	}
+}
@@ -0,0 +1,44 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type oamBugRecorder struct {
+	addrs []uint16
+}
+
+func (r *oamBugRecorder) TriggerOAMBug(addr uint16) { r.addrs = append(r.addrs, addr) }
+
+func TestIncDecOfOAMAddressTriggersOAMBug(t *testing.T) {
+	z, _ := createCPU(0x03, 0x1B, 0x23, 0x2B) // INC BC; DEC DE; INC HL; DEC HL
+	bug := &oamBugRecorder{}
+	z.OAMBug = bug
+	z.State.SetBC(0xFE00)
+	z.State.SetDE(0xFF00)
+	z.State.SetHL(0xFEFF)
+	for i := 0; i < 4; i++ {
+		step(t, z)
+	}
+	// DEC DE starts outside OAM, and DEC HL starts at 0xFF00
+	assert.Equal(t, []uint16{0xFE00, 0xFEFF}, bug.addrs)
+}
+
+func TestLoadWithHLIncrementTriggersOAMBug(t *testing.T) {
+	z, _ := createCPU(0x22, 0x3A) // LD (HL+),A; LD A,(HL-)
+	bug := &oamBugRecorder{}
+	z.OAMBug = bug
+	z.State.SetHL(0xFE10)
+	step(t, z)
+	step(t, z)
+	assert.Equal(t, []uint16{0xFE10, 0xFE11}, bug.addrs)
+}
+
+func TestOAMBugIsOptIn(t *testing.T) {
+	z, _ := createCPU(0x23) // INC HL
+	z.State.SetHL(0xFE00)
+	step(t, z)
+	assert.Equal(t, uint16(0xFE01), z.State.HL())
+}
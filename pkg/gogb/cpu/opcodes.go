@@ -0,0 +1,323 @@
+package cpu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An OperandKind describes what an instruction operand refers to.
+type OperandKind uint8
+
+// Values for OperandKind
+const (
+	// An 8-bit register: A, B, C, D, E, H or L.
+	OperandRegister8 OperandKind = iota
+	// A 16-bit register pair: AF, BC, DE, HL or SP.
+	OperandRegister16
+	// Memory pointed to by a register: (BC), (DE), (HL), (HL+), (HL-) or (C) (which addresses 0xFF00+C).
+	OperandIndirect
+	// An 8-bit immediate value (n8).
+	OperandImmediate8
+	// A 16-bit immediate value (n16).
+	OperandImmediate16
+	// A 16-bit immediate jump or call target (a16).
+	OperandAddress16
+	// Memory at a 16-bit immediate address ((a16)).
+	OperandIndirectAddress16
+	// Memory at 0xFF00 plus an 8-bit immediate ((a8)).
+	OperandHighAddress8
+	// A signed 8-bit immediate, used as a relative jump offset or added to SP (e8).
+	OperandSigned8
+	// SP plus a signed 8-bit immediate (SP+e8).
+	OperandSPOffset
+	// A branch condition: NZ, Z, NC or C.
+	OperandCondition
+	// A bit number for BIT/RES/SET: 0-7.
+	OperandBit
+	// A fixed RST vector: $00-$38.
+	OperandVector
+)
+
+// An Operand is a single operand of an instruction, as it appears in assembly.
+type Operand struct {
+	Kind OperandKind
+	Name string
+}
+
+// An Opcode describes a single instruction encoding.
+type Opcode struct {
+	// The instruction mnemonic, e.g. "LD". Illegal opcodes have an empty mnemonic.
+	Mnemonic string
+
+	// The operands, in assembly order.
+	Operands []Operand
+
+	// The length in bytes of the instruction, including any prefix and immediates.
+	Length int
+
+	// The number of T-states taken by the instruction. For conditional instructions,
+	// MinCycles is the cost when the branch is not taken and MaxCycles the cost when it is.
+	MinCycles int
+	MaxCycles int
+
+	// Set for the unused opcodes, which lock up the CPU.
+	Illegal bool
+}
+
+// Conditional returns true if the opcode takes a different number of cycles depending on whether a branch is taken.
+func (o Opcode) Conditional() bool { return o.MinCycles != o.MaxCycles }
+
+// String formats the opcode in assembly syntax with placeholder operands, e.g. "LD A,(HL+)" or "JR NZ,e8".
+func (o Opcode) String() string {
+	if o.Illegal {
+		return "ILLEGAL"
+	}
+	if len(o.Operands) == 0 {
+		return o.Mnemonic
+	}
+	names := make([]string, len(o.Operands))
+	for i, op := range o.Operands {
+		names[i] = op.Name
+	}
+	return o.Mnemonic + " " + strings.Join(names, ",")
+}
+
+// Opcodes describes every unprefixed opcode, indexed by opcode byte.
+var Opcodes = buildOpcodes()
+
+// CBOpcodes describes every opcode prefixed by 0xCB, indexed by the byte following the prefix.
+var CBOpcodes = buildCBOpcodes()
+
+// Operand tables, in the order the opcode bit fields index them.
+var (
+	operandR = [8]Operand{
+		{OperandRegister8, "B"}, {OperandRegister8, "C"}, {OperandRegister8, "D"}, {OperandRegister8, "E"},
+		{OperandRegister8, "H"}, {OperandRegister8, "L"}, {OperandIndirect, "(HL)"}, {OperandRegister8, "A"},
+	}
+	operandRP = [4]Operand{
+		{OperandRegister16, "BC"}, {OperandRegister16, "DE"}, {OperandRegister16, "HL"}, {OperandRegister16, "SP"},
+	}
+	operandRP2 = [4]Operand{
+		{OperandRegister16, "BC"}, {OperandRegister16, "DE"}, {OperandRegister16, "HL"}, {OperandRegister16, "AF"},
+	}
+	operandIndirectRP = [4]Operand{
+		{OperandIndirect, "(BC)"}, {OperandIndirect, "(DE)"}, {OperandIndirect, "(HL+)"}, {OperandIndirect, "(HL-)"},
+	}
+	operandCC = [4]Operand{
+		{OperandCondition, "NZ"}, {OperandCondition, "Z"}, {OperandCondition, "NC"}, {OperandCondition, "C"},
+	}
+
+	operandA     = Operand{OperandRegister8, "A"}
+	operandHL    = Operand{OperandRegister16, "HL"}
+	operandSP    = Operand{OperandRegister16, "SP"}
+	operandN8    = Operand{OperandImmediate8, "n8"}
+	operandN16   = Operand{OperandImmediate16, "n16"}
+	operandA16   = Operand{OperandAddress16, "a16"}
+	operandIndA  = Operand{OperandIndirectAddress16, "(a16)"}
+	operandHigh  = Operand{OperandHighAddress8, "(a8)"}
+	operandHighC = Operand{OperandIndirect, "(C)"}
+	operandE8    = Operand{OperandSigned8, "e8"}
+	operandSPE8  = Operand{OperandSPOffset, "SP+e8"}
+
+	aluMnemonics = [8]string{"ADD", "ADC", "SUB", "SBC", "AND", "XOR", "OR", "CP"}
+	rotMnemonics = [8]string{"RLC", "RRC", "RL", "RR", "SLA", "SRA", "SWAP", "SRL"}
+	accMnemonics = [8]string{"RLCA", "RRCA", "RLA", "RRA", "DAA", "CPL", "SCF", "CCF"}
+)
+
+func op(mnemonic string, length int, cycles int, operands ...Operand) Opcode {
+	return Opcode{Mnemonic: mnemonic, Operands: operands, Length: length, MinCycles: cycles, MaxCycles: cycles}
+}
+
+func branch(mnemonic string, length int, notTaken int, taken int, operands ...Operand) Opcode {
+	return Opcode{Mnemonic: mnemonic, Operands: operands, Length: length, MinCycles: notTaken, MaxCycles: taken}
+}
+
+// aluOp builds an ALU instruction, which by convention only names A explicitly for ADD, ADC and SBC.
+func aluOp(y int, length int, cycles int, src Operand) Opcode {
+	if y == 0 || y == 1 || y == 3 {
+		return op(aluMnemonics[y], length, cycles, operandA, src)
+	}
+	return op(aluMnemonics[y], length, cycles, src)
+}
+
+// buildOpcodes decodes each opcode byte as fields x (bits 7-6), y (bits 5-3) and z (bits 2-0),
+// with y further split into p (bits 5-4) and q (bit 3).
+func buildOpcodes() [256]Opcode {
+	var ops [256]Opcode
+	for i := range ops {
+		x, y, z := i>>6, (i>>3)&7, i&7
+		p, q := y>>1, y&1
+
+		// Instructions touching (HL) take an extra memory cycle
+		hlCost := 0
+		if y == 6 {
+			hlCost = 4
+		}
+
+		switch x {
+		case 0:
+			switch z {
+			case 0:
+				switch y {
+				case 0:
+					ops[i] = op("NOP", 1, 4)
+				case 1:
+					ops[i] = op("LD", 3, 20, operandIndA, operandSP)
+				case 2:
+					ops[i] = op("STOP", 2, 4, operandN8)
+				case 3:
+					ops[i] = op("JR", 2, 12, operandE8)
+				default:
+					ops[i] = branch("JR", 2, 8, 12, operandCC[y-4], operandE8)
+				}
+			case 1:
+				if q == 0 {
+					ops[i] = op("LD", 3, 12, operandRP[p], operandN16)
+				} else {
+					ops[i] = op("ADD", 1, 8, operandHL, operandRP[p])
+				}
+			case 2:
+				if q == 0 {
+					ops[i] = op("LD", 1, 8, operandIndirectRP[p], operandA)
+				} else {
+					ops[i] = op("LD", 1, 8, operandA, operandIndirectRP[p])
+				}
+			case 3:
+				if q == 0 {
+					ops[i] = op("INC", 1, 8, operandRP[p])
+				} else {
+					ops[i] = op("DEC", 1, 8, operandRP[p])
+				}
+			case 4:
+				ops[i] = op("INC", 1, 4+hlCost*2, operandR[y])
+			case 5:
+				ops[i] = op("DEC", 1, 4+hlCost*2, operandR[y])
+			case 6:
+				ops[i] = op("LD", 2, 8+hlCost, operandR[y], operandN8)
+			case 7:
+				ops[i] = op(accMnemonics[y], 1, 4)
+			}
+		case 1:
+			if y == 6 && z == 6 {
+				ops[i] = op("HALT", 1, 4)
+			} else if y == 6 || z == 6 {
+				ops[i] = op("LD", 1, 8, operandR[y], operandR[z])
+			} else {
+				ops[i] = op("LD", 1, 4, operandR[y], operandR[z])
+			}
+		case 2:
+			if z == 6 {
+				ops[i] = aluOp(y, 1, 8, operandR[z])
+			} else {
+				ops[i] = aluOp(y, 1, 4, operandR[z])
+			}
+		case 3:
+			switch z {
+			case 0:
+				switch y {
+				case 4:
+					ops[i] = op("LDH", 2, 12, operandHigh, operandA)
+				case 5:
+					ops[i] = op("ADD", 2, 16, operandSP, operandE8)
+				case 6:
+					ops[i] = op("LDH", 2, 12, operandA, operandHigh)
+				case 7:
+					ops[i] = op("LD", 2, 12, operandHL, operandSPE8)
+				default:
+					ops[i] = branch("RET", 1, 8, 20, operandCC[y])
+				}
+			case 1:
+				if q == 0 {
+					ops[i] = op("POP", 1, 12, operandRP2[p])
+				} else {
+					switch p {
+					case 0:
+						ops[i] = op("RET", 1, 16)
+					case 1:
+						ops[i] = op("RETI", 1, 16)
+					case 2:
+						ops[i] = op("JP", 1, 4, operandHL)
+					case 3:
+						ops[i] = op("LD", 1, 8, operandSP, operandHL)
+					}
+				}
+			case 2:
+				switch y {
+				case 4:
+					ops[i] = op("LDH", 1, 8, operandHighC, operandA)
+				case 5:
+					ops[i] = op("LD", 3, 16, operandIndA, operandA)
+				case 6:
+					ops[i] = op("LDH", 1, 8, operandA, operandHighC)
+				case 7:
+					ops[i] = op("LD", 3, 16, operandA, operandIndA)
+				default:
+					ops[i] = branch("JP", 3, 12, 16, operandCC[y], operandA16)
+				}
+			case 3:
+				switch y {
+				case 0:
+					ops[i] = op("JP", 3, 16, operandA16)
+				case 1:
+					// The CB prefix itself; see CBOpcodes for the full instructions.
+					ops[i] = op("PREFIX", 1, 4)
+				case 6:
+					ops[i] = op("DI", 1, 4)
+				case 7:
+					ops[i] = op("EI", 1, 4)
+				default:
+					ops[i] = Opcode{Length: 1, Illegal: true}
+				}
+			case 4:
+				if y < 4 {
+					ops[i] = branch("CALL", 3, 12, 24, operandCC[y], operandA16)
+				} else {
+					ops[i] = Opcode{Length: 1, Illegal: true}
+				}
+			case 5:
+				if q == 0 {
+					ops[i] = op("PUSH", 1, 16, operandRP2[p])
+				} else if p == 0 {
+					ops[i] = op("CALL", 3, 24, operandA16)
+				} else {
+					ops[i] = Opcode{Length: 1, Illegal: true}
+				}
+			case 6:
+				ops[i] = aluOp(y, 2, 8, operandN8)
+			case 7:
+				ops[i] = op("RST", 1, 16, Operand{OperandVector, fmt.Sprintf("$%02X", y*8)})
+			}
+		}
+	}
+	return ops
+}
+
+// buildCBOpcodes decodes each CB-prefixed opcode byte using the same x/y/z fields as buildOpcodes.
+func buildCBOpcodes() [256]Opcode {
+	var ops [256]Opcode
+	for i := range ops {
+		x, y, z := i>>6, (i>>3)&7, i&7
+
+		cycles := 8
+		if z == 6 {
+			// (HL) needs a read and a write, except for BIT which only reads
+			cycles = 16
+			if x == 1 {
+				cycles = 12
+			}
+		}
+
+		bit := Operand{OperandBit, fmt.Sprint(y)}
+		switch x {
+		case 0:
+			ops[i] = op(rotMnemonics[y], 2, cycles, operandR[z])
+		case 1:
+			ops[i] = op("BIT", 2, cycles, bit, operandR[z])
+		case 2:
+			ops[i] = op("RES", 2, cycles, bit, operandR[z])
+		case 3:
+			ops[i] = op("SET", 2, cycles, bit, operandR[z])
+		}
+	}
+	return ops
+}
@@ -0,0 +1,116 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpcodeStrings(t *testing.T) {
+	assert.Equal(t, "NOP", Opcodes[0x00].String())
+	assert.Equal(t, "LD (a16),SP", Opcodes[0x08].String())
+	assert.Equal(t, "LD A,(HL+)", Opcodes[0x2A].String())
+	assert.Equal(t, "JR NZ,e8", Opcodes[0x20].String())
+	assert.Equal(t, "LD (HL),A", Opcodes[0x77].String())
+	assert.Equal(t, "HALT", Opcodes[0x76].String())
+	assert.Equal(t, "ADC A,(HL)", Opcodes[0x8E].String())
+	assert.Equal(t, "CP n8", Opcodes[0xFE].String())
+	assert.Equal(t, "LDH (a8),A", Opcodes[0xE0].String())
+	assert.Equal(t, "LD HL,SP+e8", Opcodes[0xF8].String())
+	assert.Equal(t, "RST $38", Opcodes[0xFF].String())
+	assert.Equal(t, "PUSH AF", Opcodes[0xF5].String())
+	assert.Equal(t, "SWAP A", CBOpcodes[0x37].String())
+	assert.Equal(t, "BIT 7,(HL)", CBOpcodes[0x7E].String())
+}
+
+func TestIllegalOpcodes(t *testing.T) {
+	var illegal []int
+	for i, op := range Opcodes {
+		if op.Illegal {
+			illegal = append(illegal, i)
+		}
+	}
+	assert.Equal(t, []int{0xD3, 0xDB, 0xDD, 0xE3, 0xE4, 0xEB, 0xEC, 0xED, 0xF4, 0xFC, 0xFD}, illegal)
+}
+
+func TestOpcodeCycles(t *testing.T) {
+	assert.Equal(t, 12, Opcodes[0x34].MinCycles) // INC (HL)
+	assert.Equal(t, 8, Opcodes[0x46].MinCycles)  // LD B,(HL)
+	assert.Equal(t, 12, Opcodes[0x36].MinCycles) // LD (HL),n8
+	assert.Equal(t, 16, CBOpcodes[0x06].MinCycles)
+	assert.Equal(t, 12, CBOpcodes[0x46].MinCycles)
+
+	call := Opcodes[0xC4]
+	assert.True(t, call.Conditional())
+	assert.Equal(t, 12, call.MinCycles)
+	assert.Equal(t, 24, call.MaxCycles)
+}
+
+// Reference M-cycle counts for every unprefixed opcode, with conditional branches not taken.
+// Zero marks the opcodes that have no fixed timing: STOP, HALT, the CB prefix and the illegal opcodes.
+var referenceMCycles = [256]int{
+	1, 3, 2, 2, 1, 1, 2, 1, 5, 2, 2, 2, 1, 1, 2, 1,
+	0, 3, 2, 2, 1, 1, 2, 1, 3, 2, 2, 2, 1, 1, 2, 1,
+	2, 3, 2, 2, 1, 1, 2, 1, 2, 2, 2, 2, 1, 1, 2, 1,
+	2, 3, 2, 2, 3, 3, 3, 1, 2, 2, 2, 2, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	2, 2, 2, 2, 2, 2, 0, 2, 1, 1, 1, 1, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	2, 3, 3, 4, 3, 4, 2, 4, 2, 4, 3, 0, 3, 6, 2, 4,
+	2, 3, 3, 0, 3, 4, 2, 4, 2, 4, 3, 0, 3, 0, 2, 4,
+	3, 3, 2, 0, 0, 4, 2, 4, 4, 1, 4, 0, 0, 0, 2, 4,
+	3, 3, 2, 1, 0, 4, 2, 4, 3, 2, 4, 1, 0, 0, 2, 4,
+}
+
+// Reference M-cycle counts for the conditional branches when they are taken.
+var referenceTakenMCycles = map[int]int{
+	0x20: 3, 0x28: 3, 0x30: 3, 0x38: 3, // JR cc
+	0xC0: 5, 0xC8: 5, 0xD0: 5, 0xD8: 5, // RET cc
+	0xC2: 4, 0xCA: 4, 0xD2: 4, 0xDA: 4, // JP cc
+	0xC4: 6, 0xCC: 6, 0xD4: 6, 0xDC: 6, // CALL cc
+}
+
+func TestOpcodeCyclesMatchReference(t *testing.T) {
+	for i, op := range Opcodes {
+		if referenceMCycles[i] != 0 {
+			assert.Equal(t, referenceMCycles[i]*4, op.MinCycles, "MinCycles of %02X %s", i, op)
+		}
+
+		taken, ok := referenceTakenMCycles[i]
+		assert.Equal(t, ok, op.Conditional(), "Conditional of %02X %s", i, op)
+		if ok {
+			assert.Equal(t, taken*4, op.MaxCycles, "MaxCycles of %02X %s", i, op)
+		} else {
+			assert.Equal(t, op.MinCycles, op.MaxCycles, "MaxCycles of %02X %s", i, op)
+		}
+	}
+}
+
+func TestCBOpcodeCyclesMatchReference(t *testing.T) {
+	for i, op := range CBOpcodes {
+		// Including the prefix, register operations take 2 M-cycles, BIT n,(HL) 3 and the other (HL) operations 4
+		expected := 8
+		if i&7 == 6 {
+			expected = 16
+			if i&0xC0 == 0x40 {
+				expected = 12
+			}
+		}
+		assert.Equal(t, expected, op.MinCycles, "MinCycles of CB %02X %s", i, op)
+		assert.False(t, op.Conditional(), "Conditional of CB %02X %s", i, op)
+	}
+}
+
+func TestOpcodeLengths(t *testing.T) {
+	lengths := map[int]int{}
+	for _, op := range Opcodes {
+		lengths[op.Length]++
+	}
+	// 11 illegal opcodes and the CB prefix count as 1 byte
+	assert.Equal(t, map[int]int{1: 213, 2: 26, 3: 17}, lengths)
+}
@@ -0,0 +1,23 @@
+package cpu
+
+import "github.com/anurse/gogb/pkg/gogb/model"
+
+// The register values the boot ROM of each model leaves behind when it hands over to the cartridge at 0x0100.
+// On DMG and MGB, H and C depend on the header checksum; these are the values for a nonzero checksum,
+// which is what almost every cartridge has.
+var postBootStates = map[model.Model]State{
+	model.DMG: {A: 0x01, F: FlagZero | FlagHalfCarry | FlagCarry, B: 0x00, C: 0x13, D: 0x00, E: 0xD8, H: 0x01, L: 0x4D},
+	model.MGB: {A: 0xFF, F: FlagZero | FlagHalfCarry | FlagCarry, B: 0x00, C: 0x13, D: 0x00, E: 0xD8, H: 0x01, L: 0x4D},
+	model.SGB: {A: 0x01, F: FlagEmpty, B: 0x00, C: 0x14, D: 0x00, E: 0x00, H: 0xC0, L: 0x60},
+	model.CGB: {A: 0x11, F: FlagZero, B: 0x00, C: 0x00, D: 0xFF, E: 0x56, H: 0x00, L: 0x0D},
+}
+
+// Reset puts the CPU into the state the boot ROM of the specified model leaves it in, ready to run
+// the cartridge at 0x0100 without a boot ROM image. Games use A in particular to detect the model.
+// CGB is set to match the model; StrictStack and OnExecute are left unchanged. Memory is not touched.
+func (z *SM83) Reset(m model.Model) {
+	z.State = postBootStates[m]
+	z.State.SP = 0xFFFE
+	z.State.PC = 0x0100
+	z.CGB = m.IsCGB()
+}
@@ -0,0 +1,36 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResetUsesPostBootRegisters(t *testing.T) {
+	tests := map[model.Model]string{
+		model.DMG: "AF=01B0 BC=0013 DE=00D8 HL=014D SP=FFFE PC=0100 [Z-HC]",
+		model.MGB: "AF=FFB0 BC=0013 DE=00D8 HL=014D SP=FFFE PC=0100 [Z-HC]",
+		model.SGB: "AF=0100 BC=0014 DE=0000 HL=C060 SP=FFFE PC=0100 [----]",
+		model.CGB: "AF=1180 BC=0000 DE=FF56 HL=000D SP=FFFE PC=0100 [Z---]",
+	}
+	for m, expected := range tests {
+		z, _ := createCPU()
+		z.Reset(m)
+		assert.Equal(t, expected, z.State.String(), m.String())
+		assert.Equal(t, m == model.CGB, z.CGB, m.String())
+	}
+}
+
+func TestResetClearsExecutionState(t *testing.T) {
+	z, _ := createCPU()
+	z.StrictStack = true
+	z.State = State{TStates: 1234, IME: true, Halted: true, Locked: true, DoubleSpeed: true}
+	z.Reset(model.DMG)
+	assert.Equal(t, 0, z.State.TStates)
+	assert.False(t, z.State.IME)
+	assert.False(t, z.State.Halted)
+	assert.False(t, z.State.Locked)
+	assert.False(t, z.State.DoubleSpeed)
+	assert.True(t, z.StrictStack)
+}
@@ -0,0 +1,197 @@
+package cpu
+
+import (
+	"fmt"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+)
+
+// A Flags represents a value that can be stored in the SM83's flags register.
+// Only the upper nibble exists; the low 4 bits always read as zero.
+type Flags uint8
+
+// Set returns a new Flags with the specified flag set. If it is already set, there is no effect.
+func (f *Flags) Set(flag Flags) { *f |= flag }
+
+// Clear returns a new Flags with the specified flag clear. If it is already clear, there is no efect.
+func (f *Flags) Clear(flag Flags) { *f &= ^flag }
+
+// SetIf returns a new Flags with the specified flag set based on the condition provided.
+// If the condition is false, the flag is cleared.
+func (f *Flags) SetIf(condition bool, flag Flags) {
+	if condition {
+		f.Set(flag)
+	} else {
+		f.Clear(flag)
+	}
+}
+
+// IsSet returns a boolean indicating if the specified flag is set.
+func (f Flags) IsSet(flag Flags) bool { return f&flag != 0 }
+
+// IsClear returns a boolean indicating if the specified flag is set.
+func (f Flags) IsClear(flag Flags) bool { return f&flag == 0 }
+
+// String returns the flags in ZNHC order, with a '-' for each flag that is clear (e.g. "Z-H-").
+func (f Flags) String() string {
+	out := []byte("----")
+	for i, flag := range []Flags{FlagZero, FlagAddSub, FlagHalfCarry, FlagCarry} {
+		if f.IsSet(flag) {
+			out[i] = "ZNHC"[i]
+		}
+	}
+	return string(out)
+}
+
+// Values for Flags
+const (
+	FlagEmpty     Flags = 0
+	FlagCarry     Flags = 1 << 4
+	FlagHalfCarry Flags = 1 << 5
+	FlagAddSub    Flags = 1 << 6
+	FlagZero      Flags = 1 << 7
+)
+
+// A State describes the current state of the CPU registers and clock.
+// The 8-bit registers can also be accessed in pairs through AF, BC, DE and HL.
+type State struct {
+	A       uint8
+	B       uint8
+	C       uint8
+	D       uint8
+	E       uint8
+	H       uint8
+	L       uint8
+	F       Flags
+	PC      uint16
+	SP      uint16
+	TStates int
+
+	// IME is the interrupt master enable flag, set by EI and RETI and cleared by DI.
+	IME bool
+
+	// IMEPending is set by EI. IME is only set once the instruction after EI has executed.
+	IMEPending bool
+
+	// Locked is set when the CPU executes an illegal opcode. Step fails with ErrLocked while it is set.
+	Locked bool
+
+	// Halted is set by HALT. Step does nothing but advance the clock until an enabled interrupt is requested.
+	Halted bool
+
+	// HaltBug is set when HALT is executed with IME clear and an interrupt already pending.
+	// The next opcode fetch fails to advance PC, so the byte after HALT is read twice.
+	HaltBug bool
+
+	// Stopped is set by STOP when it enters low-power mode rather than switching speed.
+	// Step does nothing but advance the clock while it is set. Like Halted, it is cleared
+	// when an enabled interrupt becomes pending.
+	Stopped bool
+
+	// DoubleSpeed is true when a CGB is running in double speed mode. The CPU clock is
+	// unaffected, but components that run at a fixed rate must take twice as many T-states per tick.
+	// KEY1 reports it in bit 7.
+	DoubleSpeed bool
+}
+
+// String returns the registers on a single line in the conventional trace format,
+// e.g. "AF=01B0 BC=0013 DE=00D8 HL=014D SP=FFFE PC=0100 [Z-HC]".
+func (s State) String() string {
+	return fmt.Sprintf("AF=%02X%02X BC=%02X%02X DE=%02X%02X HL=%02X%02X SP=%04X PC=%04X [%s]",
+		s.A, uint8(s.F), s.B, s.C, s.D, s.E, s.H, s.L, s.SP, s.PC, s.F)
+}
+
+// MultiLine returns the registers and clock spread over several lines, for debugger views and error reports.
+func (s State) MultiLine() string {
+	return fmt.Sprintf("A=%02X F=%02X [%s]\nB=%02X C=%02X\nD=%02X E=%02X\nH=%02X L=%02X\nSP=%04X\nPC=%04X\nT=%d",
+		s.A, uint8(s.F), s.F, s.B, s.C, s.D, s.E, s.H, s.L, s.SP, s.PC, s.TStates)
+}
+
+// AF returns the A and F registers as a 16-bit pair.
+func (s State) AF() uint16 { return uint16(s.A)<<8 | uint16(s.F) }
+
+// SetAF sets the A and F registers from a 16-bit pair. The low nibble of F is always zero
+// on hardware, so it is masked off.
+func (s *State) SetAF(val uint16) {
+	s.A = uint8(val >> 8)
+	s.F = Flags(val) & 0xF0
+}
+
+// BC returns the B and C registers as a 16-bit pair.
+func (s State) BC() uint16 { return uint16(s.B)<<8 | uint16(s.C) }
+
+// SetBC sets the B and C registers from a 16-bit pair.
+func (s *State) SetBC(val uint16) { s.B, s.C = uint8(val>>8), uint8(val) }
+
+// DE returns the D and E registers as a 16-bit pair.
+func (s State) DE() uint16 { return uint16(s.D)<<8 | uint16(s.E) }
+
+// SetDE sets the D and E registers from a 16-bit pair.
+func (s *State) SetDE(val uint16) { s.D, s.E = uint8(val>>8), uint8(val) }
+
+// HL returns the H and L registers as a 16-bit pair.
+func (s State) HL() uint16 { return uint16(s.H)<<8 | uint16(s.L) }
+
+// SetHL sets the H and L registers from a 16-bit pair.
+func (s *State) SetHL(val uint16) { s.H, s.L = uint8(val>>8), uint8(val) }
+
+// A Ticker is advanced by the CPU once per M-cycle, so components running alongside it (such as OAM DMA)
+// keep time with it.
+type Ticker interface {
+	Tick()
+}
+
+// An ExecuteHook is called before the CPU executes an instruction, with the address and bytes of the
+// instruction and the registers as they are before it runs. The opcode slice must not be retained.
+type ExecuteHook func(pc uint16, opcode []byte, state State)
+
+// An SM83 represents the Sharp SM83, the CPU core of the GameBoy. It is often called a Z80, but it only
+// shares part of the Z80 instruction set: there are no IX/IY index registers, no shadow register set,
+// no I/O port instructions, and the flags register only has Z, N, H and C (in the upper nibble).
+type SM83 struct {
+	State  State
+	Memory memory.MMU
+
+	// CGB enables behavior specific to the Color GameBoy CPU, such as the STOP speed switch.
+	CGB bool
+
+	// StrictStack makes pushes and pops fail with ErrStackOverflow or ErrStackUnderflow when SP would
+	// wrap around, instead of wrapping as the hardware does. It is meant for debugging runaway stacks.
+	StrictStack bool
+
+	// OAMBug, if set, enables emulation of the DMG OAM corruption bug. Leave it nil for CGB, or when
+	// the extra accuracy isn't wanted.
+	OAMBug OAMBugTrigger
+
+	// OnExecute, if set, is called for every instruction. It is meant for tracers, debuggers and coverage tools.
+	OnExecute ExecuteHook
+
+	// Ticker, if set, is advanced at the end of every M-cycle, after the cycle's memory access.
+	Ticker Ticker
+
+	// The byte following the last CB prefix executed, for StepResult.
+	cbOpcode uint8
+}
+
+// NewSM83 returns a new SM83 with default state and the specified memory unit.
+func NewSM83(mem memory.MMU) SM83 {
+	return SM83{
+		State:  State{},
+		Memory: mem,
+	}
+}
+
+// Z80 is the old name of SM83.
+//
+// Deprecated: Use SM83. The GameBoy CPU is not a Z80.
+type Z80 = SM83
+
+// Z80Flags is the old name of Flags.
+//
+// Deprecated: Use Flags.
+type Z80Flags = Flags
+
+// NewZ80 returns a new SM83 with default state and the specified memory unit.
+//
+// Deprecated: Use NewSM83.
+func NewZ80(mem memory.MMU) SM83 { return NewSM83(mem) }
@@ -0,0 +1,104 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagsString(t *testing.T) {
+	assert.Equal(t, "----", FlagEmpty.String())
+	assert.Equal(t, "Z-H-", (FlagZero | FlagHalfCarry).String())
+	assert.Equal(t, "ZNHC", (FlagZero | FlagAddSub | FlagHalfCarry | FlagCarry).String())
+}
+
+func TestStateString(t *testing.T) {
+	s := State{A: 0x01, F: FlagZero | FlagHalfCarry | FlagCarry, B: 0x00, C: 0x13, D: 0x00, E: 0xD8, H: 0x01, L: 0x4D, SP: 0xFFFE, PC: 0x0100}
+	assert.Equal(t, "AF=01B0 BC=0013 DE=00D8 HL=014D SP=FFFE PC=0100 [Z-HC]", s.String())
+}
+
+func TestStateMultiLine(t *testing.T) {
+	s := State{A: 0x01, F: FlagZero, SP: 0xFFFE, PC: 0x0100, TStates: 24}
+	assert.Equal(t, "A=01 F=80 [Z---]\nB=00 C=00\nD=00 E=00\nH=00 L=00\nSP=FFFE\nPC=0100\nT=24", s.MultiLine())
+}
+
+func TestDiffStateReportsChangedRegisters(t *testing.T) {
+	old := State{A: 0x01, F: FlagZero, PC: 0x0100}
+	new := State{A: 0x02, F: FlagZero | FlagCarry, PC: 0x0100}
+
+	diffs := DiffState(old, new)
+	assert.Equal(t, []StateDiff{
+		{Register: "A", Old: 0x01, New: 0x02},
+		{Register: "F", Old: 0x80, New: 0x90},
+	}, diffs)
+	assert.Equal(t, "F: 80 [Z---] -> 90 [Z--C]", diffs[1].String())
+}
+
+func TestDiffStateIsEmptyForIdenticalStates(t *testing.T) {
+	s := State{A: 0x01, SP: 0xFFFE}
+	assert.Empty(t, DiffState(s, s))
+}
+
+func TestDiffStateReportsIME(t *testing.T) {
+	diffs := DiffState(State{}, State{IME: true})
+	assert.Equal(t, []StateDiff{{Register: "IME", Old: 0, New: 1}}, diffs)
+	assert.Equal(t, "IME: 0 -> 1", diffs[0].String())
+}
+
+func TestDiffStateReportsStatusFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		state State
+	}{
+		{"IMEPending", State{IMEPending: true}},
+		{"Halted", State{Halted: true}},
+		{"HaltBug", State{HaltBug: true}},
+		{"Stopped", State{Stopped: true}},
+		{"Locked", State{Locked: true}},
+		{"DoubleSpeed", State{DoubleSpeed: true}},
+	}
+	for _, tt := range tests {
+		diffs := DiffState(State{}, tt.state)
+		assert.Equal(t, []StateDiff{{Register: tt.name, Old: 0, New: 1}}, diffs, tt.name)
+		assert.Equal(t, tt.name+": 1 -> 0", DiffState(tt.state, State{})[0].String(), tt.name)
+	}
+}
+
+func TestDiffStateOrdersStatusFlagsAfterRegisters(t *testing.T) {
+	diffs := DiffState(State{}, State{PC: 0x0100, Halted: true, IME: true})
+	assert.Equal(t, []StateDiff{
+		{Register: "PC", Old: 0x0000, New: 0x0100},
+		{Register: "IME", Old: 0, New: 1},
+		{Register: "Halted", Old: 0, New: 1},
+	}, diffs)
+}
+
+func TestRegisterPairs(t *testing.T) {
+	var s State
+	s.SetBC(0x1234)
+	s.SetDE(0x5678)
+	s.SetHL(0x9ABC)
+	assert.Equal(t, uint8(0x12), s.B)
+	assert.Equal(t, uint8(0x34), s.C)
+	assert.Equal(t, uint16(0x1234), s.BC())
+	assert.Equal(t, uint16(0x5678), s.DE())
+	assert.Equal(t, uint16(0x9ABC), s.HL())
+}
+
+func TestSetAFMasksLowNibbleOfF(t *testing.T) {
+	var s State
+	s.SetAF(0x12FF)
+	assert.Equal(t, uint8(0x12), s.A)
+	assert.Equal(t, FlagZero|FlagAddSub|FlagHalfCarry|FlagCarry, s.F)
+	assert.Equal(t, uint16(0x12F0), s.AF())
+}
+
+func TestDeprecatedZ80NamesStillWork(t *testing.T) {
+	mem := memory.NewRAM(0x10)
+	var z *Z80 = &SM83{}
+	*z = NewZ80(&mem)
+	var f Z80Flags = FlagZero
+	z.State.F = f
+	assert.Equal(t, "Z---", z.State.F.String())
+}
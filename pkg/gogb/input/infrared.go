@@ -0,0 +1,20 @@
+package input
+
+// An Infrared is implemented by frontends to connect an infrared port, such as the one on HuC1 and HuC3
+// cartridges, to another emulator instance or to real hardware.
+type Infrared interface {
+	// SetLED turns the port's IR LED on or off.
+	SetLED(on bool)
+
+	// Light returns true if the port's sensor is currently receiving infrared light.
+	Light() bool
+}
+
+// A Dark Infrared has nothing at the other end: it never receives light and ignores the LED.
+type Dark struct{}
+
+// SetLED does nothing.
+func (Dark) SetLED(on bool) {}
+
+// Light always returns false.
+func (Dark) Light() bool { return false }
@@ -0,0 +1,72 @@
+package input
+
+// Calibration values for the two-axis accelerometer found on MBC7 cartridges.
+// A level cartridge reads AccelCenter on both axes, and each g of acceleration
+// moves the reading by roughly AccelPerG.
+const (
+	AccelCenter uint16 = 0x81D0
+	AccelPerG   uint16 = 0x70
+)
+
+// An Accelerometer is implemented by frontends to feed tilt data to cartridges with a motion sensor.
+type Accelerometer interface {
+	// Tilt returns the current acceleration on the X and Y axes, in g.
+	// Positive X means the right side of the GameBoy is tilted down, positive Y means the top is tilted down.
+	Tilt() (x, y float64)
+}
+
+// AccelFunc is an adapter that allows an ordinary function to be used as an Accelerometer.
+// It is useful for scripted tilt curves in tests or TAS tooling.
+type AccelFunc func() (x, y float64)
+
+// Tilt calls f().
+func (f AccelFunc) Tilt() (x, y float64) { return f() }
+
+// A Level Accelerometer always reports that the GameBoy is lying flat.
+type Level struct{}
+
+// Tilt always returns zero on both axes.
+func (Level) Tilt() (x, y float64) { return 0, 0 }
+
+// A DigitalTilt emulates an accelerometer with four digital directions, such as a keyboard or d-pad.
+// Holding a direction tilts the GameBoy by Magnitude g along that axis.
+type DigitalTilt struct {
+	Left, Right, Up, Down bool
+
+	// The tilt (in g) reported while a direction is held. Defaults to 1g if zero.
+	Magnitude float64
+}
+
+// Tilt returns the tilt corresponding to the directions currently held.
+func (d *DigitalTilt) Tilt() (x, y float64) {
+	m := d.Magnitude
+	if m == 0 {
+		m = 1
+	}
+	if d.Left {
+		x -= m
+	}
+	if d.Right {
+		x += m
+	}
+	if d.Up {
+		y -= m
+	}
+	if d.Down {
+		y += m
+	}
+	return x, y
+}
+
+// SensorValue converts an acceleration in g to the raw 16-bit value reported by the MBC7 sensor.
+// The result is clamped to the range of the register.
+func SensorValue(g float64) uint16 {
+	val := float64(AccelCenter) + g*float64(AccelPerG)
+	if val < 0 {
+		return 0
+	}
+	if val > 0xFFFF {
+		return 0xFFFF
+	}
+	return uint16(val)
+}
@@ -0,0 +1,28 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSensorValueIsCenteredWhenLevel(t *testing.T) {
+	assert.Equal(t, AccelCenter, SensorValue(0))
+}
+
+func TestSensorValueScalesByG(t *testing.T) {
+	assert.Equal(t, AccelCenter+AccelPerG, SensorValue(1))
+	assert.Equal(t, AccelCenter-2*AccelPerG, SensorValue(-2))
+}
+
+func TestSensorValueClamps(t *testing.T) {
+	assert.Equal(t, uint16(0xFFFF), SensorValue(1000))
+	assert.Equal(t, uint16(0), SensorValue(-1000))
+}
+
+func TestDigitalTiltCombinesDirections(t *testing.T) {
+	d := DigitalTilt{Right: true, Up: true, Magnitude: 0.5}
+	x, y := d.Tilt()
+	assert.Equal(t, 0.5, x)
+	assert.Equal(t, -0.5, y)
+}
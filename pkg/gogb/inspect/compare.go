@@ -0,0 +1,25 @@
+package inspect
+
+import "github.com/anurse/gogb/pkg/gogb/memory"
+
+// Compare reads the specified regions from two memories and returns every byte that differs.
+// Old is read from a and New from b. It is useful for comparing a machine against an expected memory image in tests.
+func Compare(a memory.MMU, b memory.MMU, regions ...Region) ([]Change, error) {
+	var changes []Change
+	for _, r := range regions {
+		for addr := r.Start; addr < r.End; addr++ {
+			old, err := a.GetByte(addr)
+			if err != nil {
+				return nil, err
+			}
+			new, err := b.GetByte(addr)
+			if err != nil {
+				return nil, err
+			}
+			if old != new {
+				changes = append(changes, Change{Addr: addr, Old: old, New: new})
+			}
+		}
+	}
+	return changes, nil
+}
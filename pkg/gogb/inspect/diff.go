@@ -0,0 +1,120 @@
+package inspect
+
+import (
+	"fmt"
+
+	"github.com/anurse/gogb/pkg/gogb/cpu"
+	"github.com/anurse/gogb/pkg/gogb/memory"
+)
+
+// A Change records a byte of memory that changed between two snapshots.
+type Change struct {
+	Addr int
+	Old  uint8
+	New  uint8
+
+	// WriterPC is the address of the instruction that last wrote the byte. It is only known when the
+	// Differ is attached to the CPU and saw the write, in which case HasWriter is true.
+	WriterPC  uint16
+	HasWriter bool
+}
+
+func (c Change) String() string {
+	if c.HasWriter {
+		return fmt.Sprintf("$%04X: %02X -> %02X (PC=$%04X)", c.Addr, c.Old, c.New, c.WriterPC)
+	}
+	return fmt.Sprintf("$%04X: %02X -> %02X", c.Addr, c.Old, c.New)
+}
+
+// A Differ snapshots regions of memory and reports which bytes changed since the previous snapshot.
+// Calling Diff once per frame gives a per-frame log of state changes.
+type Differ struct {
+	mem       memory.MMU
+	regions   []Region
+	snapshots [][]uint8
+
+	// Set once the Differ is attached to a CPU
+	pc      uint16
+	writers map[int]uint16
+}
+
+// NewDiffer creates a Differ and takes the initial snapshot of the specified regions.
+func NewDiffer(mem memory.MMU, regions ...Region) (*Differ, error) {
+	d := &Differ{mem: mem, regions: regions, snapshots: make([][]uint8, len(regions))}
+	for i, r := range regions {
+		d.snapshots[i] = make([]uint8, r.Len())
+		if err := d.read(r, d.snapshots[i], nil); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Attach hooks the Differ into z so that changes report the PC of the instruction that wrote them.
+// z.Memory is wrapped to observe writes and the existing OnExecute hook, if any, keeps being called.
+// Writes made while dispatching an interrupt are attributed to the last instruction executed.
+func (d *Differ) Attach(z *cpu.SM83) {
+	d.writers = make(map[int]uint16)
+	next := z.OnExecute
+	z.OnExecute = func(pc uint16, opcode []byte, state cpu.State) {
+		d.pc = pc
+		if next != nil {
+			next(pc, opcode, state)
+		}
+	}
+	z.Memory = &writeRecorder{MMU: z.Memory, d: d}
+}
+
+// Diff takes a new snapshot and returns every byte that differs from the previous one, in region order.
+func (d *Differ) Diff() ([]Change, error) {
+	var changes []Change
+	for i, r := range d.regions {
+		if err := d.read(r, d.snapshots[i], &changes); err != nil {
+			return nil, err
+		}
+	}
+	if d.writers != nil {
+		for i, c := range changes {
+			changes[i].WriterPC, changes[i].HasWriter = d.writers[c.Addr]
+		}
+		d.writers = make(map[int]uint16)
+	}
+	return changes, nil
+}
+
+func (d *Differ) read(r Region, snapshot []uint8, changes *[]Change) error {
+	for i := range snapshot {
+		val, err := d.mem.GetByte(r.Start + i)
+		if err != nil {
+			return err
+		}
+		if changes != nil && val != snapshot[i] {
+			*changes = append(*changes, Change{Addr: r.Start + i, Old: snapshot[i], New: val})
+		}
+		snapshot[i] = val
+	}
+	return nil
+}
+
+// writeRecorder passes accesses through to the CPU's memory, noting which instruction wrote each address.
+type writeRecorder struct {
+	memory.MMU
+	d *Differ
+}
+
+func (w *writeRecorder) SetByte(addr int, val uint8) error {
+	if err := w.MMU.SetByte(addr, val); err != nil {
+		return err
+	}
+	w.d.writers[addr] = w.d.pc
+	return nil
+}
+
+func (w *writeRecorder) SetWord(addr int, val uint16) error {
+	if err := w.MMU.SetWord(addr, val); err != nil {
+		return err
+	}
+	w.d.writers[addr] = w.d.pc
+	w.d.writers[addr+1] = w.d.pc
+	return nil
+}
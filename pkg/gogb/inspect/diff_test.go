@@ -0,0 +1,72 @@
+package inspect
+
+import (
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/cpu"
+	"github.com/anurse/gogb/pkg/gogb/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDifferReportsChangesSinceLastSnapshot(t *testing.T) {
+	mem := memory.NewRAM(0x20)
+	d, err := NewDiffer(&mem, Region{0x00, 0x08}, Region{0x10, 0x18})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mem.SetByte(0x03, 0xAA))
+	assert.NoError(t, mem.SetByte(0x12, 0xBB))
+	assert.NoError(t, mem.SetByte(0x0C, 0xCC)) // Not in a region
+
+	changes, err := d.Diff()
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{{Addr: 0x03, Old: 0x00, New: 0xAA}, {Addr: 0x12, Old: 0x00, New: 0xBB}}, changes)
+
+	changes, err = d.Diff()
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestAttachedDifferReportsWriterPC(t *testing.T) {
+	mem := memory.NewRAM(0x10000)
+	program := []byte{
+		0x3E, 0x42, // LD A,0x42
+		0xEA, 0x00, 0xC0, // LD (0xC000),A
+		0x3C, // INC A
+	}
+	for i, b := range program {
+		assert.NoError(t, mem.SetByte(i, b))
+	}
+	z := cpu.NewSM83(&mem)
+	d, err := NewDiffer(&mem, Region{0xC000, 0xC010})
+	assert.NoError(t, err)
+
+	var executed int
+	z.OnExecute = func(uint16, []byte, cpu.State) { executed++ }
+	d.Attach(&z)
+	for i := 0; i < 3; i++ {
+		_, err := z.Step()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, executed, "the existing hook is still called")
+
+	// A change made behind the CPU's back has no known writer
+	assert.NoError(t, mem.SetByte(0xC001, 0x01))
+
+	changes, err := d.Diff()
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Addr: 0xC000, Old: 0x00, New: 0x42, WriterPC: 0x0002, HasWriter: true},
+		{Addr: 0xC001, Old: 0x00, New: 0x01},
+	}, changes)
+	assert.Equal(t, "$C000: 00 -> 42 (PC=$0002)", changes[0].String())
+}
+
+func TestCompareReportsDifferingBytes(t *testing.T) {
+	a := memory.NewRAM(0x10)
+	b := memory.NewRAM(0x10)
+	assert.NoError(t, b.SetByte(0x04, 0x42))
+
+	changes, err := Compare(&a, &b, Region{0x00, 0x10})
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{{Addr: 0x04, Old: 0x00, New: 0x42}}, changes)
+}
@@ -0,0 +1,19 @@
+// Package inspect provides tools for examining emulated memory while a game runs,
+// such as cheat searches, watch lists and per-frame diffs.
+package inspect
+
+// A Region is a range of addresses to inspect. End is exclusive.
+type Region struct {
+	Start int
+	End   int
+}
+
+// Len returns the number of bytes in the region.
+func (r Region) Len() int { return r.End - r.Start }
+
+// Commonly searched memory regions
+var (
+	ExternalRAM = Region{0xA000, 0xC000}
+	WorkRAM     = Region{0xC000, 0xE000}
+	HighRAM     = Region{0xFF80, 0xFFFF}
+)
@@ -0,0 +1,99 @@
+package inspect
+
+import (
+	"errors"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+)
+
+// A Candidate is an address that still matches every filter applied to a Search.
+type Candidate struct {
+	Addr int
+
+	// The value at the time of the most recent filter (or the initial snapshot).
+	Value uint8
+
+	// The value at the time of the filter before that. Equal to Value after the initial snapshot.
+	Previous uint8
+}
+
+// A Comparison decides whether a candidate is kept, given its current and previous values.
+type Comparison func(current, previous uint8) bool
+
+// EqualTo keeps candidates whose current value is v.
+func EqualTo(v uint8) Comparison {
+	return func(current, _ uint8) bool { return current == v }
+}
+
+// NotEqualTo keeps candidates whose current value is not v.
+func NotEqualTo(v uint8) Comparison {
+	return func(current, _ uint8) bool { return current != v }
+}
+
+// Changed keeps candidates whose value changed since the previous filter.
+func Changed(current, previous uint8) bool { return current != previous }
+
+// Unchanged keeps candidates whose value is the same as at the previous filter.
+func Unchanged(current, previous uint8) bool { return current == previous }
+
+// GreaterThanPrevious keeps candidates whose value increased since the previous filter.
+func GreaterThanPrevious(current, previous uint8) bool { return current > previous }
+
+// LessThanPrevious keeps candidates whose value decreased since the previous filter.
+func LessThanPrevious(current, previous uint8) bool { return current < previous }
+
+// ChangedBy keeps candidates whose value changed by exactly n since the previous filter.
+// Arithmetic wraps like the 8-bit registers do, so ChangedBy(-1) matches 0x00 -> 0xFF.
+func ChangedBy(n int) Comparison {
+	return func(current, previous uint8) bool { return current == uint8(int(previous)+n) }
+}
+
+// A Search narrows down which bytes of memory hold a game variable by repeatedly filtering
+// a set of candidate addresses against how their values changed.
+type Search struct {
+	mem        memory.MMU
+	candidates []Candidate
+}
+
+// NewSearch snapshots the specified regions of memory, making every readable address a candidate.
+// Addresses the MMU reports as out of range are skipped.
+func NewSearch(mem memory.MMU, regions ...Region) (*Search, error) {
+	s := &Search{mem: mem}
+	for _, r := range regions {
+		for addr := r.Start; addr < r.End; addr++ {
+			val, err := mem.GetByte(addr)
+			if errors.Is(err, memory.ErrAddressOutOfRange) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			s.candidates = append(s.candidates, Candidate{Addr: addr, Value: val, Previous: val})
+		}
+	}
+	return s, nil
+}
+
+// Filter re-reads every remaining candidate and discards those that don't satisfy cmp.
+// If a read fails, the candidates are left as they were before the call.
+func (s *Search) Filter(cmp Comparison) error {
+	var kept []Candidate
+	for _, c := range s.candidates {
+		val, err := s.mem.GetByte(c.Addr)
+		if err != nil {
+			return err
+		}
+		if cmp(val, c.Value) {
+			kept = append(kept, Candidate{Addr: c.Addr, Value: val, Previous: c.Value})
+		}
+	}
+	s.candidates = kept
+	return nil
+}
+
+// Len returns the number of remaining candidates.
+func (s *Search) Len() int { return len(s.candidates) }
+
+// Candidates returns the remaining candidates in address order.
+func (s *Search) Candidates() []Candidate {
+	return append([]Candidate(nil), s.candidates...)
+}
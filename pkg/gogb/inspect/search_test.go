@@ -0,0 +1,67 @@
+package inspect
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchFindsDecrementingVariable(t *testing.T) {
+	mem := memory.NewRAM(0x100)
+	assert.NoError(t, mem.SetByte(0x42, 3))
+	assert.NoError(t, mem.SetByte(0x43, 3))
+
+	s, err := NewSearch(&mem, Region{0x00, 0x100})
+	assert.NoError(t, err)
+	assert.Equal(t, 0x100, s.Len())
+
+	assert.NoError(t, s.Filter(EqualTo(3)))
+	assert.Equal(t, 2, s.Len())
+
+	// Lose a life
+	assert.NoError(t, mem.SetByte(0x42, 2))
+	assert.NoError(t, s.Filter(ChangedBy(-1)))
+
+	assert.Equal(t, []Candidate{{Addr: 0x42, Value: 2, Previous: 3}}, s.Candidates())
+}
+
+func TestSearchSkipsUnmappedAddresses(t *testing.T) {
+	mem := memory.NewRAM(0x10)
+	s, err := NewSearch(&mem, Region{0x08, 0x20})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, s.Len())
+}
+
+// faultyRAM fails reads from a single address once fail is set.
+type faultyRAM struct {
+	memory.RAM
+	addr int
+	fail bool
+}
+
+func (m *faultyRAM) GetByte(addr int) (uint8, error) {
+	if m.fail && addr == m.addr {
+		return 0, errors.New("bus fault")
+	}
+	return m.RAM.GetByte(addr)
+}
+
+func TestSearchKeepsCandidatesWhenFilterFails(t *testing.T) {
+	mem := &faultyRAM{RAM: memory.NewRAM(0x10), addr: 0x0C}
+	assert.NoError(t, mem.SetByte(0x04, 1))
+	s, err := NewSearch(mem, Region{0x00, 0x10})
+	assert.NoError(t, err)
+	before := s.Candidates()
+
+	mem.fail = true
+	assert.Error(t, s.Filter(EqualTo(1)))
+	assert.Equal(t, before, s.Candidates())
+}
+
+func TestChangedByWraps(t *testing.T) {
+	assert.True(t, ChangedBy(-1)(0xFF, 0x00))
+	assert.True(t, ChangedBy(2)(0x01, 0xFF))
+	assert.False(t, ChangedBy(1)(0x01, 0x01))
+}
@@ -0,0 +1,125 @@
+package inspect
+
+import (
+	"fmt"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+)
+
+// A ValueType describes how the bytes at a watched address are interpreted.
+type ValueType uint8
+
+// Values for ValueType. Multi-byte values are little-endian, as the CPU stores them.
+const (
+	U8 ValueType = iota
+	S8
+	U16
+	S16
+	BCD8
+	BCD16
+)
+
+// Size returns the number of bytes occupied by a value of this type.
+func (t ValueType) Size() int {
+	switch t {
+	case U16, S16, BCD16:
+		return 2
+	default:
+		return 1
+	}
+}
+
+func (t ValueType) String() string {
+	return [...]string{"u8", "s8", "u16", "s16", "bcd8", "bcd16"}[t]
+}
+
+// A Watch is a labeled, typed address that is re-evaluated whenever its WatchList is updated.
+type Watch struct {
+	Label string
+	Addr  int
+	Type  ValueType
+
+	// The value as of the most recent update.
+	Value int
+}
+
+func (w Watch) String() string {
+	return fmt.Sprintf("%s ($%04X %s) = %d", w.Label, w.Addr, w.Type, w.Value)
+}
+
+// A WatchList tracks a set of watched addresses. Frontends and scripts call Update once per frame.
+type WatchList struct {
+	mem     memory.MMU
+	watches []Watch
+}
+
+// NewWatchList creates an empty WatchList reading from the specified memory.
+func NewWatchList(mem memory.MMU) *WatchList {
+	return &WatchList{mem: mem}
+}
+
+// Add registers a new watch. The value is not read until the next Update.
+func (l *WatchList) Add(label string, addr int, typ ValueType) {
+	l.watches = append(l.watches, Watch{Label: label, Addr: addr, Type: typ})
+}
+
+// Remove removes every watch with the specified label.
+func (l *WatchList) Remove(label string) {
+	kept := l.watches[:0]
+	for _, w := range l.watches {
+		if w.Label != label {
+			kept = append(kept, w)
+		}
+	}
+	l.watches = kept
+}
+
+// Update re-reads every watched value from memory.
+func (l *WatchList) Update() error {
+	for i := range l.watches {
+		val, err := readValue(l.mem, l.watches[i].Addr, l.watches[i].Type)
+		if err != nil {
+			return err
+		}
+		l.watches[i].Value = val
+	}
+	return nil
+}
+
+// Watches returns the current watches and their values as of the last Update.
+func (l *WatchList) Watches() []Watch {
+	return append([]Watch(nil), l.watches...)
+}
+
+func readValue(mem memory.MMU, addr int, typ ValueType) (int, error) {
+	lo, err := mem.GetByte(addr)
+	if err != nil {
+		return 0, err
+	}
+	var hi uint8
+	if typ.Size() == 2 {
+		hi, err = mem.GetByte(addr + 1)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	switch typ {
+	case S8:
+		return int(int8(lo)), nil
+	case U16:
+		return int(hi)<<8 | int(lo), nil
+	case S16:
+		return int(int16(uint16(hi)<<8 | uint16(lo))), nil
+	case BCD8:
+		return bcd(lo), nil
+	case BCD16:
+		return bcd(hi)*100 + bcd(lo), nil
+	default:
+		return int(lo), nil
+	}
+}
+
+func bcd(b uint8) int {
+	return int(b>>4)*10 + int(b&0x0F)
+}
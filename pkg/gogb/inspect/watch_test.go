@@ -0,0 +1,42 @@
+package inspect
+
+import (
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchListDecodesTypes(t *testing.T) {
+	mem := memory.NewRAM(0x10)
+	assert.NoError(t, mem.SetByte(0x00, 0xFE))
+	assert.NoError(t, mem.SetByte(0x02, 0x34))
+	assert.NoError(t, mem.SetByte(0x03, 0x12))
+	assert.NoError(t, mem.SetByte(0x04, 0x99))
+
+	l := NewWatchList(&mem)
+	l.Add("u8", 0x00, U8)
+	l.Add("s8", 0x00, S8)
+	l.Add("u16", 0x02, U16)
+	l.Add("bcd8", 0x04, BCD8)
+	l.Add("bcd16", 0x02, BCD16)
+	assert.NoError(t, l.Update())
+
+	var values []int
+	for _, w := range l.Watches() {
+		values = append(values, w.Value)
+	}
+	assert.Equal(t, []int{0xFE, -2, 0x1234, 99, 1234}, values)
+}
+
+func TestWatchListRemove(t *testing.T) {
+	mem := memory.NewRAM(0x10)
+	l := NewWatchList(&mem)
+	l.Add("lives", 0x00, U8)
+	l.Add("score", 0x02, BCD16)
+	l.Remove("lives")
+
+	watches := l.Watches()
+	assert.Len(t, watches, 1)
+	assert.Equal(t, "score", watches[0].Label)
+}
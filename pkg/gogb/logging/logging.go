@@ -0,0 +1,143 @@
+// Package logging provides component-scoped structured logging for the emulator.
+// Each subsystem logs through a named component whose level can be changed at runtime,
+// so a single noisy subsystem can be traced without drowning in output from the others.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// Names of the emulator components.
+const (
+	CPU       = "cpu"
+	PPU       = "ppu"
+	APU       = "apu"
+	Mapper    = "mapper"
+	Serial    = "serial"
+	DMA       = "dma"
+	Cartridge = "cartridge"
+)
+
+// LevelTrace is more verbose than slog.LevelDebug, for per-instruction or per-access output.
+const LevelTrace slog.Level = slog.LevelDebug - 4
+
+var (
+	mu           sync.RWMutex
+	output       slog.Handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: LevelTrace})
+	defaultLevel              = new(slog.LevelVar)
+	levels                    = make(map[string]*slog.LevelVar)
+)
+
+func init() {
+	defaultLevel.Set(slog.LevelWarn)
+}
+
+// For returns the logger for the named component.
+// Records are tagged with a "component" attribute and filtered by the component's level.
+func For(component string) *slog.Logger {
+	return slog.New(&componentHandler{component: component}).With("component", component)
+}
+
+// SetOutput replaces the handler every component writes to. Filtering by level happens before
+// records reach the handler, so it should accept every level.
+func SetOutput(h slog.Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	output = h
+}
+
+// SetTextOutput writes every component's records to w as text.
+func SetTextOutput(w io.Writer) {
+	SetOutput(slog.NewTextHandler(w, &slog.HandlerOptions{Level: LevelTrace}))
+}
+
+// SetDefaultLevel sets the level for every component that has not been given its own level.
+func SetDefaultLevel(level slog.Level) {
+	defaultLevel.Set(level)
+}
+
+// SetLevel sets the level for a single component, overriding the default level.
+func SetLevel(component string, level slog.Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	if v, ok := levels[component]; ok {
+		v.Set(level)
+		return
+	}
+	v := new(slog.LevelVar)
+	v.Set(level)
+	levels[component] = v
+}
+
+// ResetLevel removes a component's own level, so it follows the default level again.
+func ResetLevel(component string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(levels, component)
+}
+
+// Level returns the level in effect for a component.
+func Level(component string) slog.Level {
+	mu.RLock()
+	defer mu.RUnlock()
+	if v, ok := levels[component]; ok {
+		return v.Level()
+	}
+	return defaultLevel.Level()
+}
+
+// Verbosity converts a count of -v flags into a level: none shows warnings and errors,
+// then each -v adds info, debug and trace output.
+func Verbosity(count int) slog.Level {
+	switch {
+	case count <= 0:
+		return slog.LevelWarn
+	case count == 1:
+		return slog.LevelInfo
+	case count == 2:
+		return slog.LevelDebug
+	default:
+		return LevelTrace
+	}
+}
+
+// componentHandler filters records by the level of its component and forwards them to the current output.
+// The output is looked up on each record so SetOutput takes effect for loggers that already exist,
+// which means attributes and groups have to be replayed onto it in the order they were added.
+type componentHandler struct {
+	component string
+	wrappers  []func(slog.Handler) slog.Handler
+}
+
+func (h *componentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= Level(h.component)
+}
+
+func (h *componentHandler) Handle(ctx context.Context, r slog.Record) error {
+	mu.RLock()
+	out := output
+	mu.RUnlock()
+
+	for _, wrap := range h.wrappers {
+		out = wrap(out)
+	}
+	return out.Handle(ctx, r)
+}
+
+func (h *componentHandler) with(wrap func(slog.Handler) slog.Handler) slog.Handler {
+	clone := *h
+	clone.wrappers = append(append([]func(slog.Handler) slog.Handler(nil), h.wrappers...), wrap)
+	return &clone
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithAttrs(attrs) })
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithGroup(name) })
+}
@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func captureOutput(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	SetOutput(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: LevelTrace,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	t.Cleanup(func() {
+		SetTextOutput(os.Stderr)
+		SetDefaultLevel(slog.LevelWarn)
+		ResetLevel(CPU)
+	})
+	return &buf
+}
+
+func TestComponentLevelOverridesDefault(t *testing.T) {
+	buf := captureOutput(t)
+	SetDefaultLevel(slog.LevelWarn)
+	SetLevel(CPU, slog.LevelDebug)
+
+	For(CPU).Debug("executing", "pc", 0x0100)
+	For(PPU).Debug("hidden")
+	For(PPU).Warn("shown")
+
+	assert.Equal(t, "level=DEBUG msg=executing component=cpu pc=256\nlevel=WARN msg=shown component=ppu\n", buf.String())
+}
+
+func TestResetLevelFallsBackToDefault(t *testing.T) {
+	captureOutput(t)
+	SetDefaultLevel(slog.LevelInfo)
+	SetLevel(APU, slog.LevelError)
+	assert.Equal(t, slog.LevelError, Level(APU))
+
+	ResetLevel(APU)
+	assert.Equal(t, slog.LevelInfo, Level(APU))
+}
+
+func TestGroupsAndAttrsKeepTheirOrder(t *testing.T) {
+	buf := captureOutput(t)
+	SetDefaultLevel(slog.LevelInfo)
+
+	For(Mapper).With("bank", 1).WithGroup("rtc").Info("latched", "seconds", 30)
+	assert.Equal(t, "level=INFO msg=latched component=mapper bank=1 rtc.seconds=30\n", buf.String())
+}
+
+func TestVerbosity(t *testing.T) {
+	assert.Equal(t, slog.LevelWarn, Verbosity(0))
+	assert.Equal(t, slog.LevelInfo, Verbosity(1))
+	assert.Equal(t, slog.LevelDebug, Verbosity(2))
+	assert.Equal(t, LevelTrace, Verbosity(5))
+}
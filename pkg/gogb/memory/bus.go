@@ -0,0 +1,241 @@
+package memory
+
+import "github.com/anurse/gogb/pkg/gogb/model"
+
+// Boundaries of the regions of the GameBoy memory map
+const (
+	ROMStart         = 0x0000
+	VRAMStart        = 0x8000
+	ExternalRAMStart = 0xA000
+	WRAMStart        = 0xC000
+	EchoStart        = 0xE000
+	OAMStart         = 0xFE00
+	UnusableStart    = 0xFEA0
+	IOStart          = 0xFF00
+	HRAMStart        = 0xFF80
+	IEAddr           = 0xFFFF
+)
+
+// openBus is the value read from an address nothing drives.
+const openBus = 0xFF
+
+// A Bus implements MMU over the full GameBoy memory map, routing every address in 0x0000-0xFFFF to the
+// component that owns it. Components receive the full bus address, so a cartridge can serve both its ROM
+// and its external RAM. Regions with no component read as 0xFF and ignore writes, like an open bus.
+//
+// The prohibited area between OAM and the IO registers (0xFEA0-0xFEFF) has no component; it behaves
+// as the selected Model does. Writes to it are ignored.
+//
+// Echo RAM (0xE000-0xFDFF) mirrors 0xC000-0xDDFF: accesses are passed on to WRAM at the mirrored address.
+//
+// Word accesses are split into two byte accesses, low byte first, since a word may straddle two regions.
+type Bus struct {
+	// The hardware model, which determines what reads from the prohibited area return.
+	Model model.Model
+
+	// Cartridge ROM, including the mapper registers written through it (0x0000-0x7FFF).
+	ROM MMU
+
+	// Video RAM (0x8000-0x9FFF).
+	VRAM MMU
+
+	// External RAM on the cartridge (0xA000-0xBFFF).
+	ExternalRAM MMU
+
+	// Work RAM (0xC000-0xDFFF), which echo RAM mirrors.
+	WRAM MMU
+
+	// Object attribute memory (0xFE00-0xFE9F).
+	OAM MMU
+
+	// IO registers (0xFF00-0xFF7F).
+	IO MMU
+
+	// High RAM (0xFF80-0xFFFE).
+	HRAM MMU
+
+	// The interrupt enable register (0xFFFF).
+	IE MMU
+
+	// The OAM DMA controller, which also owns IODMA. While it runs, the CPU's accesses outside
+	// 0xFF00-0xFFFF may conflict with the transfer.
+	DMA *DMA
+}
+
+// NewBus creates a Bus for the specified model, with plain RAM for WRAM, OAM, HRAM and IE, VRAM with
+// the banks of the model, an OAM DMA controller, and IORegisters owning nothing but the VRAM bank and
+// DMA registers. The cartridge regions are left empty for the caller to fill in.
+func NewBus(m model.Model) *Bus {
+	vram := NewVRAM(m)
+	io := NewIORegisters(m)
+	if m.IsCGB() {
+		io.Map(IOVRAMBank, vram)
+	}
+	b := &Bus{
+		Model: m,
+		VRAM:  vram,
+		WRAM:  NewWindow(WRAMStart, 0x2000),
+		OAM:   NewWindow(OAMStart, 0xA0),
+		IO:    io,
+		HRAM:  NewWindow(HRAMStart, 0x7F),
+		IE:    NewWindow(IEAddr, 1),
+	}
+	b.DMA = NewDMA(b)
+	io.Map(IODMA, b.DMA)
+	return b
+}
+
+// route returns the component mapped at addr, which may be nil, and the address to pass on to it.
+func (b *Bus) route(addr int) (MMU, int) {
+	switch {
+	case addr < VRAMStart:
+		return b.ROM, addr
+	case addr < ExternalRAMStart:
+		return b.VRAM, addr
+	case addr < WRAMStart:
+		return b.ExternalRAM, addr
+	case addr < EchoStart:
+		return b.WRAM, addr
+	case addr < OAMStart:
+		return b.WRAM, addr - (EchoStart - WRAMStart)
+	case addr < UnusableStart:
+		return b.OAM, addr
+	case addr < IOStart:
+		// The prohibited area is handled by the bus itself
+		return nil, addr
+	case addr < HRAMStart:
+		return b.IO, addr
+	case addr < IEAddr:
+		return b.HRAM, addr
+	default:
+		return b.IE, addr
+	}
+}
+
+// GetByte reads a byte from the component mapped at addr.
+// Returns ErrAddressOutOfRange if the address is outside the 16-bit address space.
+func (b *Bus) GetByte(addr int) (uint8, error) {
+	if addr < 0 || addr > 0xFFFF {
+		return 0, ErrAddressOutOfRange
+	}
+	if b.DMA != nil {
+		if val, blocked := b.DMA.conflict(addr); blocked {
+			return val, nil
+		}
+	}
+	return b.get(addr)
+}
+
+// get reads a byte from the component mapped at addr, regardless of any DMA in progress.
+func (b *Bus) get(addr int) (uint8, error) {
+	mmu, addr := b.route(addr)
+	if mmu == nil {
+		if addr >= UnusableStart && addr < IOStart {
+			return b.prohibited(addr), nil
+		}
+		return openBus, nil
+	}
+	return mmu.GetByte(addr)
+}
+
+// prohibited returns the value read from addr in the prohibited area. The DMG family reads 0x00
+// (0xFF while the PPU blocks OAM, which it never does yet). Later CGB revisions return the high nibble
+// of the low address byte in both nibbles, e.g. 0xFEB4 reads 0xBB.
+func (b *Bus) prohibited(addr int) uint8 {
+	if b.Model.IsCGB() {
+		hi := uint8(addr) & 0xF0
+		return hi | hi>>4
+	}
+	return 0x00
+}
+
+// GetWord reads a little-endian word as two byte reads.
+func (b *Bus) GetWord(addr int) (uint16, error) {
+	lo, err := b.GetByte(addr)
+	if err != nil {
+		return 0, err
+	}
+	hi, err := b.GetByte(addr + 1)
+	return uint16(lo) | uint16(hi)<<8, err
+}
+
+// SetByte writes a byte to the component mapped at addr.
+// Returns ErrAddressOutOfRange if the address is outside the 16-bit address space.
+func (b *Bus) SetByte(addr int, val uint8) error {
+	if addr < 0 || addr > 0xFFFF {
+		return ErrAddressOutOfRange
+	}
+	if b.DMA != nil {
+		if _, blocked := b.DMA.conflict(addr); blocked {
+			return nil
+		}
+	}
+	mmu, addr := b.route(addr)
+	if mmu == nil {
+		return nil
+	}
+	return mmu.SetByte(addr, val)
+}
+
+// Tick advances the components that run alongside the CPU by one M-cycle.
+// Connect it to the CPU as its Ticker.
+func (b *Bus) Tick() {
+	if b.DMA != nil {
+		b.DMA.Tick()
+	}
+}
+
+// SetWord writes a little-endian word as two byte writes.
+func (b *Bus) SetWord(addr int, val uint16) error {
+	if err := b.SetByte(addr, uint8(val)); err != nil {
+		return err
+	}
+	return b.SetByte(addr+1, uint8(val>>8))
+}
+
+// A Window is a RAM that appears at a base address other than zero, so it can be mapped onto the Bus.
+type Window struct {
+	Base int
+	RAM  RAM
+}
+
+// NewWindow creates an empty Window of the specified size starting at base.
+func NewWindow(base int, size int) *Window {
+	return &Window{Base: base, RAM: NewRAM(size)}
+}
+
+// GetByte reads the byte at addr, relative to the bus rather than the window.
+// Returns ErrAddressOutOfRange if the address is outside the window.
+func (w *Window) GetByte(addr int) (uint8, error) {
+	if addr < w.Base {
+		return 0, ErrAddressOutOfRange
+	}
+	return w.RAM.GetByte(addr - w.Base)
+}
+
+// GetWord reads the little-endian word at addr, relative to the bus rather than the window.
+// Returns ErrAddressOutOfRange if the address is outside the window.
+func (w *Window) GetWord(addr int) (uint16, error) {
+	if addr < w.Base {
+		return 0, ErrAddressOutOfRange
+	}
+	return w.RAM.GetWord(addr - w.Base)
+}
+
+// SetByte writes the byte at addr, relative to the bus rather than the window.
+// Returns ErrAddressOutOfRange if the address is outside the window.
+func (w *Window) SetByte(addr int, val uint8) error {
+	if addr < w.Base {
+		return ErrAddressOutOfRange
+	}
+	return w.RAM.SetByte(addr-w.Base, val)
+}
+
+// SetWord writes the little-endian word at addr, relative to the bus rather than the window.
+// Returns ErrAddressOutOfRange if the address is outside the window.
+func (w *Window) SetWord(addr int, val uint16) error {
+	if addr < w.Base {
+		return ErrAddressOutOfRange
+	}
+	return w.RAM.SetWord(addr-w.Base, val)
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBusRoutesEachRegion(t *testing.T) {
+	bus := &Bus{}
+	regions := []struct {
+		addr int
+		slot *MMU
+	}{
+		{0x0150, &bus.ROM},
+		{0x8010, &bus.VRAM},
+		{0xA000, &bus.ExternalRAM},
+		{0xDFFF, &bus.WRAM},
+		{0xFE9F, &bus.OAM},
+		{0xFF40, &bus.IO},
+		{0xFF80, &bus.HRAM},
+		{0xFFFF, &bus.IE},
+	}
+	for _, r := range regions {
+		mem := NewScriptedMMU()
+		*r.slot = mem
+		assert.NoError(t, bus.SetByte(r.addr, 0x42))
+		assert.Equal(t, []Access{{Kind: AccessWrite, Addr: r.addr, Value: 0x42}}, mem.Accesses, "0x%04X", r.addr)
+		*r.slot = nil
+	}
+}
+
+func TestBusEmptyRegionsAreOpenBus(t *testing.T) {
+	bus := NewBus(model.DMG)
+	assert.NoError(t, bus.SetByte(0x2000, 0x01))
+	val, err := bus.GetByte(0x4000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFF), val)
+	val, err = bus.GetByte(0xA000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFF), val)
+}
+
+func TestBusWordsStraddleRegions(t *testing.T) {
+	bus := NewBus(model.DMG)
+	assert.NoError(t, bus.SetWord(0xFFFE, 0x1F42))
+
+	hram, _ := bus.GetByte(0xFFFE)
+	ie, _ := bus.GetByte(0xFFFF)
+	assert.Equal(t, uint8(0x42), hram)
+	assert.Equal(t, uint8(0x1F), ie)
+
+	word, err := bus.GetWord(0xFFFE)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0x1F42), word)
+}
+
+func TestBusRejectsAddressesOutsideAddressSpace(t *testing.T) {
+	bus := NewBus(model.DMG)
+	_, err := bus.GetByte(0x10000)
+	assert.Equal(t, ErrAddressOutOfRange, err)
+	assert.Equal(t, ErrAddressOutOfRange, bus.SetWord(0xFFFF, 0))
+}
+
+func TestBusMirrorsWRAMInEchoRAM(t *testing.T) {
+	bus := NewBus(model.DMG)
+	assert.NoError(t, bus.SetByte(0xE010, 0x42))
+	val, _ := bus.GetByte(0xC010)
+	assert.Equal(t, uint8(0x42), val)
+
+	assert.NoError(t, bus.SetByte(0xDDFF, 0x24))
+	val, _ = bus.GetByte(0xFDFF)
+	assert.Equal(t, uint8(0x24), val)
+
+	// The component sees the mirrored address
+	wram := NewScriptedMMU()
+	bus.WRAM = wram
+	bus.GetByte(0xF123)
+	assert.Equal(t, 0xD123, wram.Accesses[0].Addr)
+}
+
+func TestBusProhibitedAreaDependsOnModel(t *testing.T) {
+	for _, m := range []model.Model{model.DMG, model.MGB, model.SGB} {
+		bus := NewBus(m)
+		val, err := bus.GetByte(0xFEB4)
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(0x00), val, "%s", m)
+	}
+
+	bus := NewBus(model.CGB)
+	val, _ := bus.GetByte(0xFEB4)
+	assert.Equal(t, uint8(0xBB), val)
+	val, _ = bus.GetByte(0xFEFF)
+	assert.Equal(t, uint8(0xFF), val)
+}
+
+func TestBusIgnoresWritesToProhibitedArea(t *testing.T) {
+	bus := NewBus(model.DMG)
+	assert.NoError(t, bus.SetByte(0xFEA0, 0x42))
+	val, _ := bus.GetByte(0xFEA0)
+	assert.Equal(t, uint8(0x00), val)
+}
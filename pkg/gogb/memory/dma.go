@@ -0,0 +1,113 @@
+package memory
+
+// Timing and size of an OAM DMA transfer
+const (
+	AddrDMA = 0xFF46
+
+	// The number of bytes copied, one per M-cycle
+	DMALength = 0xA0
+)
+
+// A DMA is the OAM DMA controller. Writing XX to 0xFF46 copies XX00-XX9F to OAM, one byte per M-cycle,
+// after one M-cycle of setup following the write. Sources from 0xE000 up read the WRAM that echo RAM mirrors.
+//
+// While a transfer runs, the DMA owns the bus it reads from: the external bus (ROM, cartridge RAM and WRAM)
+// or the video bus (VRAM). CPU reads from that bus see the byte being transferred instead, CPU writes to it
+// are lost, and OAM reads as 0xFF. Only the IO registers, HRAM and IE (0xFF00-0xFFFF) are always safe, which
+// is why games run the wait loop from HRAM. CGB has WRAM on a separate bus, which isn't modeled.
+type DMA struct {
+	bus *Bus
+
+	// The last value written to 0xFF46
+	reg uint8
+
+	// The M-cycles left before the transfer starts, and the next byte to copy
+	delay  int
+	index  int
+	active bool
+
+	// The last byte copied, which is what the CPU sees when its access conflicts with the transfer
+	last uint8
+}
+
+// NewDMA creates an idle DMA controller that copies from and to the specified bus.
+func NewDMA(bus *Bus) *DMA {
+	return &DMA{bus: bus, index: DMALength}
+}
+
+// Active returns true while a transfer is blocking the CPU's access to the bus.
+func (d *DMA) Active() bool { return d.active }
+
+// Tick advances the DMA by one M-cycle, copying the next byte of a transfer in progress.
+func (d *DMA) Tick() {
+	if d.delay > 0 {
+		d.delay--
+		return
+	}
+	if d.index >= DMALength {
+		d.active = false
+		return
+	}
+	d.active = true
+
+	src := int(d.reg)<<8 | d.index
+	if src >= EchoStart {
+		src -= EchoStart - WRAMStart
+	}
+	val, err := d.bus.get(src)
+	if err != nil {
+		val = openBus
+	}
+	if d.bus.OAM != nil {
+		d.bus.OAM.SetByte(OAMStart+d.index, val)
+	}
+	d.last = val
+	d.index++
+}
+
+// conflict returns the value the CPU reads from addr, and true, if the access conflicts with a running transfer.
+func (d *DMA) conflict(addr int) (uint8, bool) {
+	if !d.active || addr >= IOStart {
+		return 0, false
+	}
+	if addr >= OAMStart {
+		return openBus, true
+	}
+	if isVideoBus(addr) == isVideoBus(int(d.reg)<<8) {
+		return d.last, true
+	}
+	return 0, false
+}
+
+// isVideoBus returns true if addr is on the video bus rather than the external bus.
+func isVideoBus(addr int) bool {
+	return addr >= VRAMStart && addr < ExternalRAMStart
+}
+
+// GetByte reads back the last value written to 0xFF46.
+// Returns ErrAddressOutOfRange for any other address.
+func (d *DMA) GetByte(addr int) (uint8, error) {
+	if addr != AddrDMA {
+		return 0, ErrAddressOutOfRange
+	}
+	return d.reg, nil
+}
+
+// GetWord is not supported, as the DMA has a single register.
+func (d *DMA) GetWord(addr int) (uint16, error) { return 0, ErrAddressOutOfRange }
+
+// SetByte starts a transfer from the page in val, restarting any transfer in progress.
+// Returns ErrAddressOutOfRange for any other address.
+func (d *DMA) SetByte(addr int, val uint8) error {
+	if addr != AddrDMA {
+		return ErrAddressOutOfRange
+	}
+	d.reg = val
+	d.index = 0
+	// The write's own M-cycle and the setup M-cycle pass before the first byte is copied
+	d.delay = 2
+	return nil
+}
+
+// SetWord is not supported, as the DMA has a single register.
+func (d *DMA) SetWord(addr int, val uint16) error { return ErrAddressOutOfRange }
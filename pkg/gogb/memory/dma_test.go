@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// createDMABus creates a bus with distinct data in WRAM page 0xC1.
+func createDMABus() *Bus {
+	bus := NewBus(model.DMG)
+	for i := 0; i < DMALength; i++ {
+		bus.SetByte(0xC100+i, uint8(i+1))
+	}
+	return bus
+}
+
+func tick(bus *Bus, cycles int) {
+	for i := 0; i < cycles; i++ {
+		bus.Tick()
+	}
+}
+
+func TestDMACopiesPageToOAM(t *testing.T) {
+	bus := createDMABus()
+	assert.NoError(t, bus.SetByte(AddrDMA, 0xC1))
+	val, _ := bus.GetByte(AddrDMA)
+	assert.Equal(t, uint8(0xC1), val)
+
+	// The write's M-cycle and the setup M-cycle, then one byte per M-cycle
+	tick(bus, 2+DMALength-1)
+	oam := bus.OAM.(*Window)
+	assert.Equal(t, uint8(DMALength-1), oam.RAM.data[DMALength-2])
+	assert.Equal(t, uint8(0), oam.RAM.data[DMALength-1])
+	assert.True(t, bus.DMA.Active())
+
+	tick(bus, 1)
+	assert.Equal(t, uint8(DMALength), oam.RAM.data[DMALength-1])
+	tick(bus, 1)
+	assert.False(t, bus.DMA.Active())
+	val, _ = bus.GetByte(0xFE00)
+	assert.Equal(t, uint8(1), val)
+}
+
+func TestDMABlocksConflictingAccesses(t *testing.T) {
+	bus := createDMABus()
+	bus.SetByte(0xFF80, 0x42)
+	bus.SetByte(0x9000, 0x24)
+	bus.SetByte(AddrDMA, 0xC1)
+	tick(bus, 2+3)
+
+	// The external bus returns the byte being transferred, and OAM is unreadable
+	val, _ := bus.GetByte(0xD000)
+	assert.Equal(t, uint8(3), val)
+	val, _ = bus.GetByte(0xFE00)
+	assert.Equal(t, uint8(0xFF), val)
+	assert.NoError(t, bus.SetByte(0xC000, 0x99))
+
+	// VRAM is on the other bus, and HRAM is always safe
+	val, _ = bus.GetByte(0x9000)
+	assert.Equal(t, uint8(0x24), val)
+	val, _ = bus.GetByte(0xFF80)
+	assert.Equal(t, uint8(0x42), val)
+
+	tick(bus, DMALength)
+	val, _ = bus.GetByte(0xC000)
+	assert.Equal(t, uint8(0x00), val, "write during DMA is lost")
+}
+
+func TestDMAFromVRAMBlocksVideoBus(t *testing.T) {
+	bus := NewBus(model.DMG)
+	bus.SetByte(0x8000, 0x11)
+	bus.SetByte(0xC000, 0x22)
+	bus.SetByte(AddrDMA, 0x80)
+	tick(bus, 3)
+
+	val, _ := bus.GetByte(0x9FFF)
+	assert.Equal(t, uint8(0x11), val)
+	val, _ = bus.GetByte(0xC000)
+	assert.Equal(t, uint8(0x22), val)
+}
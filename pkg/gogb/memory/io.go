@@ -0,0 +1,143 @@
+package memory
+
+import "github.com/anurse/gogb/pkg/gogb/model"
+
+// An IORange is the block of IO registers owned by one subsystem. Both ends are inclusive.
+type IORange struct {
+	Name       string
+	Start, End int
+}
+
+// The IO registers owned by each subsystem
+var (
+	IOJoypad     = IORange{"joypad", 0xFF00, 0xFF00}
+	IOSerial     = IORange{"serial", 0xFF01, 0xFF02}
+	IOTimer      = IORange{"timer", 0xFF04, 0xFF07}
+	IOInterrupts = IORange{"interrupts", 0xFF0F, 0xFF0F}
+	IOAPU        = IORange{"apu", 0xFF10, 0xFF3F}
+	IOPPU        = IORange{"ppu", 0xFF40, 0xFF4B}
+	IODMA        = IORange{"dma", 0xFF46, 0xFF46}
+	IOSpeed      = IORange{"speed", 0xFF4D, 0xFF4D}
+	IOVRAMBank   = IORange{"vram bank", 0xFF4F, 0xFF4F}
+	IOBootROM    = IORange{"boot rom", 0xFF50, 0xFF50}
+	IOHDMA       = IORange{"hdma", 0xFF51, 0xFF55}
+	IOInfrared   = IORange{"infrared", 0xFF56, 0xFF56}
+	IOPalettes   = IORange{"palettes", 0xFF68, 0xFF6C}
+	IOWRAMBank   = IORange{"wram bank", 0xFF70, 0xFF70}
+)
+
+// dmgUnusedBits are the bits of each IO register that always read as 1 on the DMG.
+// Addresses with no register read as 0xFF.
+var dmgUnusedBits = [0x80]uint8{
+	// Joypad, serial, timer and IF
+	0xC0, 0x00, 0x7E, 0xFF, 0x00, 0x00, 0x00, 0xF8, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xE0,
+	// Sound channels 1-3
+	0x80, 0x3F, 0x00, 0xFF, 0xBF, 0xFF, 0x3F, 0x00, 0xFF, 0xBF, 0x7F, 0xFF, 0x9F, 0xFF, 0xBF, 0xFF,
+	// Sound channel 4 and control
+	0xFF, 0x00, 0x00, 0xBF, 0x00, 0x00, 0x70, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+	// Wave RAM
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	// LCD
+	0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xFF, 0xFF, 0xFF, 0xFF,
+	0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+	0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+	0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+}
+
+// cgbUnusedBits overrides dmgUnusedBits for the registers that differ on the CGB.
+var cgbUnusedBits = map[int]uint8{
+	0xFF02: 0x7C, // SC gains the clock speed bit
+	0xFF4D: 0x7E, // KEY1
+	0xFF4F: 0xFE, // VBK
+	0xFF55: 0x00, // HDMA5
+	0xFF56: 0x3C, // RP
+	0xFF68: 0x40, // BCPS
+	0xFF69: 0x00, // BCPD
+	0xFF6A: 0x40, // OCPS
+	0xFF6B: 0x00, // OCPD
+	0xFF6C: 0xFE, // OPRI
+	0xFF70: 0xF8, // SVBK
+	0xFF72: 0x00,
+	0xFF73: 0x00,
+	0xFF75: 0x8F,
+}
+
+// An IORegisters maps the IO registers (0xFF00-0xFF7F) onto the Bus, dispatching each register to the
+// subsystem that owns it. Components receive the full bus address.
+//
+// Registers that no component owns yet behave like the real register as far as the unused bits go: the
+// value written is kept, but unused bits always read as 1, and addresses with no register read as 0xFF.
+type IORegisters struct {
+	owners [0x80]MMU
+	unused [0x80]uint8
+	values [0x80]uint8
+}
+
+// NewIORegisters creates an IORegisters with the unused bits of the specified model and no owners.
+func NewIORegisters(m model.Model) *IORegisters {
+	r := &IORegisters{unused: dmgUnusedBits}
+	if m.IsCGB() {
+		for addr, bits := range cgbUnusedBits {
+			r.unused[addr-IOStart] = bits
+		}
+	}
+	return r
+}
+
+// Map hands the registers in the range to a component, replacing any previous owner.
+func (r *IORegisters) Map(rng IORange, owner MMU) {
+	for addr := rng.Start; addr <= rng.End; addr++ {
+		r.owners[addr-IOStart] = owner
+	}
+}
+
+// Owner returns the component that owns the register at addr, or nil if none does.
+func (r *IORegisters) Owner(addr int) MMU {
+	if addr < IOStart || addr >= HRAMStart {
+		return nil
+	}
+	return r.owners[addr-IOStart]
+}
+
+// GetByte reads a register from its owner, or the stored value with the unused bits set.
+// Returns ErrAddressOutOfRange if the address is not an IO register.
+func (r *IORegisters) GetByte(addr int) (uint8, error) {
+	if addr < IOStart || addr >= HRAMStart {
+		return 0, ErrAddressOutOfRange
+	}
+	if owner := r.owners[addr-IOStart]; owner != nil {
+		return owner.GetByte(addr)
+	}
+	return r.values[addr-IOStart] | r.unused[addr-IOStart], nil
+}
+
+// GetWord reads a little-endian word as two byte reads.
+func (r *IORegisters) GetWord(addr int) (uint16, error) {
+	lo, err := r.GetByte(addr)
+	if err != nil {
+		return 0, err
+	}
+	hi, err := r.GetByte(addr + 1)
+	return uint16(lo) | uint16(hi)<<8, err
+}
+
+// SetByte writes a register to its owner, or stores the value.
+// Returns ErrAddressOutOfRange if the address is not an IO register.
+func (r *IORegisters) SetByte(addr int, val uint8) error {
+	if addr < IOStart || addr >= HRAMStart {
+		return ErrAddressOutOfRange
+	}
+	if owner := r.owners[addr-IOStart]; owner != nil {
+		return owner.SetByte(addr, val)
+	}
+	r.values[addr-IOStart] = val
+	return nil
+}
+
+// SetWord writes a little-endian word as two byte writes.
+func (r *IORegisters) SetWord(addr int, val uint16) error {
+	if err := r.SetByte(addr, uint8(val)); err != nil {
+		return err
+	}
+	return r.SetByte(addr+1, uint8(val>>8))
+}
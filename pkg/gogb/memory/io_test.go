@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIORegistersDispatchToOwner(t *testing.T) {
+	io := NewIORegisters(model.DMG)
+	timer := NewScriptedMMU()
+	io.Map(IOTimer, timer)
+
+	assert.NoError(t, io.SetByte(0xFF05, 0x42))
+	assert.NoError(t, io.SetByte(0xFF08, 0x42))
+	assert.Equal(t, []Access{{Kind: AccessWrite, Addr: 0xFF05, Value: 0x42}}, timer.Accesses)
+	assert.Equal(t, timer, io.Owner(0xFF07))
+	assert.Nil(t, io.Owner(0xFF08))
+}
+
+func TestIORegistersLaterMapsTakePrecedence(t *testing.T) {
+	io := NewIORegisters(model.DMG)
+	ppu, dma := NewScriptedMMU(), NewScriptedMMU()
+	io.Map(IOPPU, ppu)
+	io.Map(IODMA, dma)
+	assert.Equal(t, ppu, io.Owner(0xFF45))
+	assert.Equal(t, dma, io.Owner(0xFF46))
+}
+
+func TestIORegistersUnownedRegistersHaveUnusedBits(t *testing.T) {
+	io := NewIORegisters(model.DMG)
+	assert.NoError(t, io.SetByte(0xFF0F, 0x01))
+	val, err := io.GetByte(0xFF0F)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xE1), val)
+
+	// No register at all
+	assert.NoError(t, io.SetByte(0xFF03, 0x00))
+	val, _ = io.GetByte(0xFF03)
+	assert.Equal(t, uint8(0xFF), val)
+
+	// Wave RAM is fully readable
+	assert.NoError(t, io.SetByte(0xFF30, 0x5A))
+	val, _ = io.GetByte(0xFF30)
+	assert.Equal(t, uint8(0x5A), val)
+}
+
+func TestIORegistersCGBOnlyRegisters(t *testing.T) {
+	dmg := NewIORegisters(model.DMG)
+	cgb := NewIORegisters(model.CGB)
+	for _, io := range []*IORegisters{dmg, cgb} {
+		assert.NoError(t, io.SetByte(0xFF70, 0x02))
+	}
+	val, _ := dmg.GetByte(0xFF70)
+	assert.Equal(t, uint8(0xFF), val)
+	val, _ = cgb.GetByte(0xFF70)
+	assert.Equal(t, uint8(0xFA), val)
+}
+
+func TestIORegistersRejectOtherAddresses(t *testing.T) {
+	io := NewIORegisters(model.DMG)
+	_, err := io.GetByte(0xFF80)
+	assert.Equal(t, ErrAddressOutOfRange, err)
+	assert.Equal(t, ErrAddressOutOfRange, io.SetByte(0xFEFF, 0))
+}
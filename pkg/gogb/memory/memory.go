@@ -23,7 +23,7 @@ type RAM struct {
 }
 
 // NewRAM creates a new empty RAM of the specified size
-func NewRAM(size uint16) RAM {
+func NewRAM(size int) RAM {
 	return RAM{data: make([]uint8, size)}
 }
 
@@ -36,13 +36,13 @@ func (r *RAM) GetByte(addr int) (uint8, error) {
 	return r.data[addr], nil
 }
 
-// GetWord reads a 2-byte big-endian word at the specified address.
+// GetWord reads a 2-byte little-endian word (the byte order used by the CPU) at the specified address.
 // Returns ErrAddressOutOfRange if the address is outside the bounds of this RAM
 func (r *RAM) GetWord(addr int) (uint16, error) {
 	if addr+1 >= len(r.data) {
 		return 0, ErrAddressOutOfRange
 	}
-	return (uint16(r.data[addr]) << 8) | uint16(r.data[addr+1]), nil
+	return uint16(r.data[addr]) | (uint16(r.data[addr+1]) << 8), nil
 }
 
 // SetByte writes a single byte at the specified address.
@@ -55,13 +55,13 @@ func (r *RAM) SetByte(addr int, val uint8) error {
 	return nil
 }
 
-// SetWord writes a 2-byte big-endian word at the specified address.
+// SetWord writes a 2-byte little-endian word (the byte order used by the CPU) at the specified address.
 // Returns ErrAddressOutOfRange if the address is outside the bounds of this RAM
 func (r *RAM) SetWord(addr int, val uint16) error {
 	if addr+1 >= len(r.data) {
 		return ErrAddressOutOfRange
 	}
-	r.data[addr] = uint8((val & 0xFF00) >> 8)
-	r.data[addr+1] = uint8(val & 0x00FF)
+	r.data[addr] = uint8(val & 0x00FF)
+	r.data[addr+1] = uint8((val & 0xFF00) >> 8)
 	return nil
 }
@@ -0,0 +1,17 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRAMStoresWordsLittleEndian(t *testing.T) {
+	mem := NewRAM(4)
+	assert.NoError(t, mem.SetWord(1, 0xBEEF))
+
+	lo, _ := mem.GetByte(1)
+	hi, _ := mem.GetByte(2)
+	assert.Equal(t, uint8(0xEF), lo)
+	assert.Equal(t, uint8(0xBE), hi)
+}
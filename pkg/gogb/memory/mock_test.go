@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordingMMURecordsAccessesInOrder(t *testing.T) {
+	ram := NewRAM(0x10)
+	mem := NewRecordingMMU(&ram)
+
+	assert.NoError(t, mem.SetByte(0x02, 0x42))
+	val, err := mem.GetByte(0x02)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x42), val)
+	assert.NoError(t, mem.SetWord(0x04, 0xBEEF))
+
+	assert.Equal(t, []Access{
+		{Kind: AccessWrite, Addr: 0x02, Value: 0x42},
+		{Kind: AccessRead, Addr: 0x02, Value: 0x42},
+		{Kind: AccessWrite, Addr: 0x04, Value: 0xBEEF, Word: true},
+	}, mem.Accesses)
+}
+
+func TestRecordingMMURecordsFailedAccesses(t *testing.T) {
+	ram := NewRAM(0x10)
+	mem := NewRecordingMMU(&ram)
+
+	_, err := mem.GetByte(0x20)
+	assert.Equal(t, ErrAddressOutOfRange, err)
+	assert.Len(t, mem.Accesses, 1)
+}
+
+func TestScriptedMMUReturnsSequence(t *testing.T) {
+	mem := NewScriptedMMU()
+	mem.Script(0xFF44, 0x90, 0x91, 0x92)
+
+	var vals []uint8
+	for i := 0; i < 4; i++ {
+		val, err := mem.GetByte(0xFF44)
+		assert.NoError(t, err)
+		vals = append(vals, val)
+	}
+	assert.Equal(t, []uint8{0x90, 0x91, 0x92, 0x92}, vals)
+
+	val, _ := mem.GetByte(0x1234)
+	assert.Equal(t, uint8(0xFF), val)
+}
+
+func TestScriptedMMUReadsBackWrites(t *testing.T) {
+	mem := NewScriptedMMU()
+	assert.NoError(t, mem.SetWord(0xC000, 0xBEEF))
+	val, err := mem.GetWord(0xC000)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0xBEEF), val)
+}
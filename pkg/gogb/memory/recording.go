@@ -0,0 +1,76 @@
+package memory
+
+import "fmt"
+
+// An AccessKind indicates whether a memory access was a read or a write.
+type AccessKind uint8
+
+// Values for AccessKind
+const (
+	AccessRead AccessKind = iota
+	AccessWrite
+)
+
+func (k AccessKind) String() string {
+	return [...]string{"Read", "Write"}[k]
+}
+
+// An Access records a single memory access made through a RecordingMMU or ScriptedMMU.
+type Access struct {
+	Kind AccessKind
+	Addr int
+
+	// The value read or written. For byte accesses only the low 8 bits are used.
+	Value uint16
+
+	// Set if the access was made through GetWord or SetWord.
+	Word bool
+}
+
+func (a Access) String() string {
+	if a.Word {
+		return fmt.Sprintf("%s word 0x%04X = 0x%04X", a.Kind, a.Addr, a.Value)
+	}
+	return fmt.Sprintf("%s byte 0x%04X = 0x%02X", a.Kind, a.Addr, a.Value)
+}
+
+// A RecordingMMU wraps another MMU and logs every access made through it, in order.
+// It lets tests assert the exact bus activity of an instruction.
+type RecordingMMU struct {
+	Inner    MMU
+	Accesses []Access
+}
+
+// NewRecordingMMU creates a RecordingMMU that delegates to the specified MMU.
+func NewRecordingMMU(inner MMU) *RecordingMMU {
+	return &RecordingMMU{Inner: inner}
+}
+
+// Reset clears the recorded accesses.
+func (r *RecordingMMU) Reset() { r.Accesses = nil }
+
+// GetByte reads a byte from the inner MMU and records the access.
+func (r *RecordingMMU) GetByte(addr int) (uint8, error) {
+	val, err := r.Inner.GetByte(addr)
+	r.Accesses = append(r.Accesses, Access{Kind: AccessRead, Addr: addr, Value: uint16(val)})
+	return val, err
+}
+
+// GetWord reads a word from the inner MMU and records the access.
+func (r *RecordingMMU) GetWord(addr int) (uint16, error) {
+	val, err := r.Inner.GetWord(addr)
+	r.Accesses = append(r.Accesses, Access{Kind: AccessRead, Addr: addr, Value: val, Word: true})
+	return val, err
+}
+
+// SetByte records the access and writes a byte to the inner MMU.
+func (r *RecordingMMU) SetByte(addr int, val uint8) error {
+	r.Accesses = append(r.Accesses, Access{Kind: AccessWrite, Addr: addr, Value: uint16(val)})
+	return r.Inner.SetByte(addr, val)
+}
+
+// SetWord records the access and writes a word to the inner MMU.
+func (r *RecordingMMU) SetWord(addr int, val uint16) error {
+	r.Accesses = append(r.Accesses, Access{Kind: AccessWrite, Addr: addr, Value: val, Word: true})
+	return r.Inner.SetWord(addr, val)
+}
@@ -0,0 +1,64 @@
+package memory
+
+// A ScriptedMMU returns programmed values for each address, which is useful for
+// simulating hardware registers whose value changes between reads.
+// Every access is recorded in Accesses.
+type ScriptedMMU struct {
+	// The value returned when reading an address with no script.
+	Default uint8
+
+	Accesses []Access
+
+	scripts map[int][]uint8
+}
+
+// NewScriptedMMU creates a ScriptedMMU where every unscripted address reads as 0xFF, like an open bus.
+func NewScriptedMMU() *ScriptedMMU {
+	return &ScriptedMMU{Default: 0xFF, scripts: make(map[int][]uint8)}
+}
+
+// Script sets the sequence of values returned by successive reads of addr.
+// Once the sequence is exhausted, the last value is returned for every subsequent read.
+func (s *ScriptedMMU) Script(addr int, values ...uint8) {
+	s.scripts[addr] = append([]uint8(nil), values...)
+}
+
+func (s *ScriptedMMU) next(addr int) uint8 {
+	script, ok := s.scripts[addr]
+	if !ok || len(script) == 0 {
+		return s.Default
+	}
+	if len(script) > 1 {
+		s.scripts[addr] = script[1:]
+	}
+	return script[0]
+}
+
+// GetByte returns the next scripted value for the address.
+func (s *ScriptedMMU) GetByte(addr int) (uint8, error) {
+	val := s.next(addr)
+	s.Accesses = append(s.Accesses, Access{Kind: AccessRead, Addr: addr, Value: uint16(val)})
+	return val, nil
+}
+
+// GetWord returns the next scripted values for the address and the one after it, as a little-endian word.
+func (s *ScriptedMMU) GetWord(addr int) (uint16, error) {
+	val := uint16(s.next(addr)) | uint16(s.next(addr+1))<<8
+	s.Accesses = append(s.Accesses, Access{Kind: AccessRead, Addr: addr, Value: val, Word: true})
+	return val, nil
+}
+
+// SetByte records the write and replaces the script for the address, so the value written is read back.
+func (s *ScriptedMMU) SetByte(addr int, val uint8) error {
+	s.Accesses = append(s.Accesses, Access{Kind: AccessWrite, Addr: addr, Value: uint16(val)})
+	s.scripts[addr] = []uint8{val}
+	return nil
+}
+
+// SetWord records the write and replaces the scripts for both bytes of the little-endian word.
+func (s *ScriptedMMU) SetWord(addr int, val uint16) error {
+	s.Accesses = append(s.Accesses, Access{Kind: AccessWrite, Addr: addr, Value: val, Word: true})
+	s.scripts[addr] = []uint8{uint8(val)}
+	s.scripts[addr+1] = []uint8{uint8(val >> 8)}
+	return nil
+}
@@ -0,0 +1,73 @@
+package memory
+
+import "github.com/anurse/gogb/pkg/gogb/model"
+
+// Addresses of the VRAM bank select register and the size of each VRAM bank
+const (
+	AddrVBK      = 0xFF4F
+	VRAMBankSize = 0x2000
+)
+
+// A VRAM is the video RAM. The CGB has two banks, switched through the VBK register (0xFF4F): bank 1 holds
+// the tile attribute maps and extra tile data. VRAM serves both 0x8000-0x9FFF and VBK, so it must also be
+// mapped as the owner of IOVRAMBank on a CGB. On other models only bank 0 exists.
+type VRAM struct {
+	// The contents of both banks, which the PPU reads directly.
+	Banks [2][VRAMBankSize]uint8
+
+	cgb  bool
+	bank uint8
+}
+
+// NewVRAM creates an empty VRAM for the specified model.
+func NewVRAM(m model.Model) *VRAM {
+	return &VRAM{cgb: m.IsCGB()}
+}
+
+// Bank returns the bank currently mapped at 0x8000-0x9FFF.
+func (v *VRAM) Bank() int { return int(v.bank) }
+
+// GetByte reads VRAM through the current bank, or VBK, whose unused upper bits read as 1.
+// Returns ErrAddressOutOfRange for any other address.
+func (v *VRAM) GetByte(addr int) (uint8, error) {
+	switch {
+	case addr >= VRAMStart && addr < VRAMStart+VRAMBankSize:
+		return v.Banks[v.bank][addr-VRAMStart], nil
+	case addr == AddrVBK && v.cgb:
+		return 0xFE | v.bank, nil
+	default:
+		return 0, ErrAddressOutOfRange
+	}
+}
+
+// GetWord reads a little-endian word as two byte reads.
+func (v *VRAM) GetWord(addr int) (uint16, error) {
+	lo, err := v.GetByte(addr)
+	if err != nil {
+		return 0, err
+	}
+	hi, err := v.GetByte(addr + 1)
+	return uint16(lo) | uint16(hi)<<8, err
+}
+
+// SetByte writes VRAM through the current bank, or selects the bank through VBK.
+// Returns ErrAddressOutOfRange for any other address.
+func (v *VRAM) SetByte(addr int, val uint8) error {
+	switch {
+	case addr >= VRAMStart && addr < VRAMStart+VRAMBankSize:
+		v.Banks[v.bank][addr-VRAMStart] = val
+	case addr == AddrVBK && v.cgb:
+		v.bank = val & 0x01
+	default:
+		return ErrAddressOutOfRange
+	}
+	return nil
+}
+
+// SetWord writes a little-endian word as two byte writes.
+func (v *VRAM) SetWord(addr int, val uint16) error {
+	if err := v.SetByte(addr, uint8(val)); err != nil {
+		return err
+	}
+	return v.SetByte(addr+1, uint8(val>>8))
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVRAMBankSwitchingOnCGB(t *testing.T) {
+	bus := NewBus(model.CGB)
+	vram := bus.VRAM.(*VRAM)
+
+	assert.NoError(t, bus.SetByte(0x9800, 0x11))
+	assert.NoError(t, bus.SetByte(AddrVBK, 0x01))
+	assert.NoError(t, bus.SetByte(0x9800, 0x22))
+	assert.Equal(t, uint8(0x11), vram.Banks[0][0x1800])
+	assert.Equal(t, uint8(0x22), vram.Banks[1][0x1800])
+	assert.Equal(t, 1, vram.Bank())
+
+	// Only bit 0 is used; the rest read as 1
+	val, err := bus.GetByte(AddrVBK)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0xFF), val)
+	assert.NoError(t, bus.SetByte(AddrVBK, 0xFE))
+	val, _ = bus.GetByte(AddrVBK)
+	assert.Equal(t, uint8(0xFE), val)
+	val, _ = bus.GetByte(0x9800)
+	assert.Equal(t, uint8(0x11), val)
+}
+
+func TestVRAMHasOneBankOnDMG(t *testing.T) {
+	bus := NewBus(model.DMG)
+	assert.NoError(t, bus.SetByte(AddrVBK, 0x01))
+	assert.NoError(t, bus.SetByte(0x8000, 0x42))
+	assert.Equal(t, uint8(0x42), bus.VRAM.(*VRAM).Banks[0][0])
+
+	val, _ := bus.GetByte(AddrVBK)
+	assert.Equal(t, uint8(0xFF), val)
+}
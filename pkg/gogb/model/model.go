@@ -0,0 +1,38 @@
+// Package model identifies the GameBoy hardware revisions that the emulator can model.
+// It is shared by the CPU, memory and cartridge packages, which all differ slightly between models.
+package model
+
+import "fmt"
+
+// A Model is a GameBoy hardware revision.
+type Model uint8
+
+// Values for Model
+const (
+	// The original GameBoy.
+	DMG Model = iota
+	// The GameBoy Pocket (and GameBoy Light).
+	MGB
+	// The Super GameBoy cartridge for the SNES.
+	SGB
+	// The GameBoy Color.
+	CGB
+)
+
+func (m Model) String() string {
+	switch m {
+	case DMG:
+		return "DMG"
+	case MGB:
+		return "MGB"
+	case SGB:
+		return "SGB"
+	case CGB:
+		return "CGB"
+	default:
+		return fmt.Sprintf("Unknown(%d)", uint8(m))
+	}
+}
+
+// IsCGB returns true if the model has the Color GameBoy hardware (double speed, VRAM and WRAM banking, and so on).
+func (m Model) IsCGB() bool { return m == CGB }
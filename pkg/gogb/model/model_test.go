@@ -0,0 +1,19 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelString(t *testing.T) {
+	assert.Equal(t, "DMG", DMG.String())
+	assert.Equal(t, "CGB", CGB.String())
+	assert.Equal(t, "Unknown(9)", Model(9).String())
+}
+
+func TestIsCGB(t *testing.T) {
+	assert.False(t, DMG.IsCGB())
+	assert.False(t, SGB.IsCGB())
+	assert.True(t, CGB.IsCGB())
+}
@@ -105,6 +105,11 @@ func (v CartridgeType) String() string {
 	}
 }
 
+// Known returns true if the cartridge type is one of the defined CartridgeType values.
+func (v CartridgeType) Known() bool {
+	return !strings.HasPrefix(v.String(), "Unknown")
+}
+
 // A CgbSupport value indicates if a cartridge supports CGB (Color GameBoy) features
 type CgbSupport uint8
 
@@ -119,8 +124,88 @@ func (v CgbSupport) String() string {
 	return [...]string{"NotSupported", "Supported", "Required"}[v]
 }
 
+// A ROMSize is the ROM size code stored in a cartridge header.
+type ROMSize byte
+
+// Known returns true if the size code is one of the values used by real cartridges.
+func (s ROMSize) Known() bool {
+	return s <= 0x08 || (s >= 0x52 && s <= 0x54)
+}
+
+// Banks returns the number of 16KB ROM banks, or 0 if the size code is unknown.
+func (s ROMSize) Banks() int {
+	switch {
+	case s <= 0x08:
+		return 2 << s
+	case s == 0x52:
+		return 72
+	case s == 0x53:
+		return 80
+	case s == 0x54:
+		return 96
+	default:
+		return 0
+	}
+}
+
+// Bytes returns the ROM size in bytes, or 0 if the size code is unknown.
+func (s ROMSize) Bytes() int { return s.Banks() * 16 * 1024 }
+
+// KB returns the ROM size in kilobytes, or 0 if the size code is unknown.
+func (s ROMSize) KB() int { return s.Banks() * 16 }
+
+func (s ROMSize) String() string {
+	if !s.Known() {
+		return fmt.Sprintf("Unknown(0x%02X)", uint8(s))
+	}
+	return fmt.Sprintf("%dKB", s.KB())
+}
+
+// A RAMSize is the external RAM size code stored in a cartridge header.
+type RAMSize byte
+
+// Known returns true if the size code is one of the values used by real cartridges.
+func (s RAMSize) Known() bool { return s <= 0x05 }
+
+// KB returns the external RAM size in kilobytes, or 0 if the cartridge has no RAM or the size code is unknown.
+func (s RAMSize) KB() int {
+	switch s {
+	case 0x01:
+		return 2
+	case 0x02:
+		return 8
+	case 0x03:
+		return 32
+	case 0x04:
+		return 128
+	case 0x05:
+		return 64
+	default:
+		return 0
+	}
+}
+
+// Bytes returns the external RAM size in bytes, or 0 if the cartridge has no RAM or the size code is unknown.
+func (s RAMSize) Bytes() int { return s.KB() * 1024 }
+
+// Banks returns the number of 8KB RAM banks. A 2KB RAM occupies a single (partial) bank.
+func (s RAMSize) Banks() int { return (s.KB() + 7) / 8 }
+
+func (s RAMSize) String() string {
+	if !s.Known() {
+		return fmt.Sprintf("Unknown(0x%02X)", uint8(s))
+	}
+	return fmt.Sprintf("%dKB", s.KB())
+}
+
 // A CartridgeHeader represents the header of a GBA ROM.
 type CartridgeHeader struct {
+	// The 4 bytes at the entry point (0x0100), usually a NOP followed by a JP to the start of the game.
+	EntryPoint [4]byte
+
+	// The logo bitmap as stored in the ROM. The boot ROM requires it to match NintendoLogo.
+	Logo [48]byte
+
 	// The title of the ROM.
 	Title string
 
@@ -139,11 +224,11 @@ type CartridgeHeader struct {
 	// A value indicating the type of the cartridge.
 	Type CartridgeType
 
-	// A value indicating the ROM size (in KB) of the cartridge.
-	ROMSize int
+	// The ROM size of the cartridge.
+	ROMSize ROMSize
 
-	// A value indicating the RAM size (in KB) of the cartridge.
-	RAMSize int
+	// The external RAM size of the cartridge.
+	RAMSize RAMSize
 
 	// A boolean indicating if this version of the game is to be sold in Japan.
 	Japanese bool
@@ -154,10 +239,23 @@ type CartridgeHeader struct {
 	// The version number of the game.
 	VersionNumber byte
 
+	// The header checksum as stored in the ROM.
+	HeaderChecksum byte
+
+	// The header checksum computed from the header data. If it differs from HeaderChecksum, ParseHeader returns ErrHeaderChecksumInvalid.
+	ComputedHeaderChecksum byte
+
 	// A global checksum over the cartridge data.
 	GlobalChecksum uint16
 }
 
+// NintendoLogo is the logo bitmap that must appear at 0x0104-0x0133 of every cartridge for the boot ROM to accept it.
+var NintendoLogo = [48]byte{
+	0xCE, 0xED, 0x66, 0x66, 0xCC, 0x0D, 0x00, 0x0B, 0x03, 0x73, 0x00, 0x83, 0x00, 0x0C, 0x00, 0x0D,
+	0x00, 0x08, 0x11, 0x1F, 0x88, 0x89, 0x00, 0x0E, 0xDC, 0xCC, 0x6E, 0xE6, 0xDD, 0xDD, 0xD9, 0x99,
+	0xBB, 0xBB, 0x67, 0x63, 0x6E, 0x0E, 0xEC, 0xCC, 0xDD, 0xDC, 0x99, 0x9F, 0xBB, 0xB9, 0x33, 0x3E,
+}
+
 // ErrHeaderLengthInvalid indicates that the provided header data was not the correct size.
 var ErrHeaderLengthInvalid error = errors.New("header data is not 0x50 bytes long")
 
@@ -166,11 +264,27 @@ var ErrHeaderChecksumInvalid error = errors.New("the header checksum could not b
 
 // ParseHeader parses the provided header and fills in the CartridgeHeader struct provided.
 // If ErrHeaderChecksumInvalid is returned, the provided CartridgeHeader struct will **still** be filled in with data!
+// Use ParseHeaderWithOptions to get a full list of anomalies instead.
 func ParseHeader(inp []byte, header *CartridgeHeader) error {
+	err := ParseHeaderWithOptions(inp, header, ParseHeaderOptions{})
+	if errors.Is(err, ErrHeaderChecksumInvalid) {
+		return ErrHeaderChecksumInvalid
+	}
+	return err
+}
+
+// ParseHeaderWithOptions parses the provided header and fills in the CartridgeHeader struct provided.
+// Every anomaly found is collected and returned together in a *HeaderError, in which case the
+// CartridgeHeader struct is **still** filled in. A header checksum mismatch is always reported;
+// the other checks only run in strict mode.
+func ParseHeaderWithOptions(inp []byte, header *CartridgeHeader, opts ParseHeaderOptions) error {
 	if len(inp) != 0x50 {
 		return ErrHeaderLengthInvalid
 	}
 
+	copy(header.EntryPoint[:], inp[0x00:0x04])
+	copy(header.Logo[:], inp[0x04:0x34])
+
 	// Read the title
 	header.Title = strings.TrimRight(string(inp[0x34:0x3F]), "\x00")
 	header.ManufacturerCode = strings.TrimRight(string(inp[0x3F:0x42]), "\x00")
@@ -184,14 +298,14 @@ func ParseHeader(inp []byte, header *CartridgeHeader) error {
 		header.CGBSupport = CgbNotSupported
 	}
 
-	header.NewLicenseeCode = string(inp[0x44:0x45])
+	header.NewLicenseeCode = strings.TrimRight(string(inp[0x44:0x46]), "\x00")
 
 	header.SGBSupport = inp[0x46] == 0x03
 
 	header.Type = CartridgeType(inp[0x47])
 
-	header.ROMSize = getROMSize(inp[0x48])
-	header.RAMSize = getRAMSize(inp[0x49])
+	header.ROMSize = ROMSize(inp[0x48])
+	header.RAMSize = RAMSize(inp[0x49])
 
 	header.Japanese = inp[0x4A] == 0x00
 
@@ -202,62 +316,24 @@ func ParseHeader(inp []byte, header *CartridgeHeader) error {
 	header.GlobalChecksum = binary.BigEndian.Uint16(inp[0x4E:0x50])
 
 	// Compute checksum. We still fill the header structure even if the checksum fails, but we want to return an error so the user knows
-	headerChecksum := inp[0x4D]
-	var actualChecksum byte
+	header.HeaderChecksum = inp[0x4D]
+	header.ComputedHeaderChecksum = 0
 
 	for x := 0x34; x <= 0x4C; x++ {
-		actualChecksum = actualChecksum - inp[x] - 1
+		header.ComputedHeaderChecksum = header.ComputedHeaderChecksum - inp[x] - 1
 	}
 
-	if headerChecksum != actualChecksum {
-		return ErrHeaderChecksumInvalid
+	var anomalies []Anomaly
+	if header.HeaderChecksum != header.ComputedHeaderChecksum {
+		anomalies = append(anomalies, Anomaly{AnomalyHeaderChecksum, 0x014D,
+			fmt.Sprintf("header checksum is 0x%02X but the header data sums to 0x%02X", header.HeaderChecksum, header.ComputedHeaderChecksum)})
 	}
-
-	return nil
-}
-
-func getROMSize(size byte) int {
-	switch size {
-	case 0x00:
-		return 2 * 16
-	case 0x01:
-		return 4 * 16
-	case 0x02:
-		return 8 * 16
-	case 0x03:
-		return 16 * 16
-	case 0x04:
-		return 32 * 16
-	case 0x05:
-		return 128 * 16
-	case 0x06:
-		return 256 * 16
-	case 0x08:
-		return 512 * 16
-	case 0x52:
-		return 72 * 16
-	case 0x53:
-		return 80 * 16
-	case 0x54:
-		return 96 * 16
-	default:
-		return 0
+	if opts.Strict {
+		anomalies = append(anomalies, strictAnomalies(inp, header)...)
 	}
-}
 
-func getRAMSize(size byte) int {
-	switch size {
-	case 0x01:
-		return 2
-	case 0x02:
-		return 8
-	case 0x03:
-		return 32
-	case 0x04:
-		return 128
-	case 0x05:
-		return 64
-	default:
-		return 0
+	if len(anomalies) > 0 {
+		return &HeaderError{Anomalies: anomalies}
 	}
+	return nil
 }
@@ -0,0 +1,136 @@
+package gogb
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func FuzzParseHeader(f *testing.F) {
+	rom, err := ioutil.ReadFile("../../testroms/cpu_instrs/cpu_instrs.gb")
+	if err == nil {
+		f.Add(rom[0x0100:0x0150])
+	}
+	f.Add(make([]byte, 0x50))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var header CartridgeHeader
+		err := ParseHeader(data, &header)
+		if len(data) != 0x50 && err != ErrHeaderLengthInvalid {
+			t.Errorf("expected ErrHeaderLengthInvalid for %d bytes of input, got %v", len(data), err)
+		}
+		_ = header.Type.String()
+		_ = header.CGBSupport.String()
+	})
+}
+
+func TestParseHeaderExposesRawFields(t *testing.T) {
+	rom, err := ioutil.ReadFile("../../testroms/cpu_instrs/cpu_instrs.gb")
+	assert.NoError(t, err)
+
+	var header CartridgeHeader
+	assert.NoError(t, ParseHeader(rom[0x0100:0x0150], &header))
+	assert.Equal(t, [4]byte{0x00, 0xC3, 0x37, 0x06}, header.EntryPoint)
+	assert.Equal(t, NintendoLogo, header.Logo)
+	assert.Equal(t, byte(0x3B), header.HeaderChecksum)
+	assert.Equal(t, byte(0x3B), header.ComputedHeaderChecksum)
+}
+
+func TestParseHeaderReportsComputedChecksumOnMismatch(t *testing.T) {
+	inp := make([]byte, 0x50)
+	inp[0x4D] = 0x12
+
+	var header CartridgeHeader
+	assert.Equal(t, ErrHeaderChecksumInvalid, ParseHeader(inp, &header))
+	assert.Equal(t, byte(0x12), header.HeaderChecksum)
+	assert.Equal(t, byte(0xE7), header.ComputedHeaderChecksum)
+}
+
+func TestROMSize(t *testing.T) {
+	assert.Equal(t, 2, ROMSize(0x00).Banks())
+	assert.Equal(t, 32*1024, ROMSize(0x00).Bytes())
+	assert.Equal(t, 64, ROMSize(0x05).Banks())
+	assert.Equal(t, 512, ROMSize(0x08).Banks())
+	assert.Equal(t, 72, ROMSize(0x52).Banks())
+	assert.Equal(t, "1024KB", ROMSize(0x05).String())
+
+	assert.False(t, ROMSize(0x09).Known())
+	assert.Equal(t, 0, ROMSize(0x09).Banks())
+	assert.Equal(t, "Unknown(0x09)", ROMSize(0x09).String())
+}
+
+func TestRAMSize(t *testing.T) {
+	assert.Equal(t, 0, RAMSize(0x00).Banks())
+	assert.Equal(t, 1, RAMSize(0x01).Banks())
+	assert.Equal(t, 4, RAMSize(0x03).Banks())
+	assert.Equal(t, 64*1024, RAMSize(0x05).Bytes())
+
+	assert.False(t, RAMSize(0x06).Known())
+	assert.Equal(t, "Unknown(0x06)", RAMSize(0x06).String())
+}
+
+func validHeader() []byte {
+	inp := make([]byte, 0x50)
+	copy(inp[0x04:0x34], NintendoLogo[:])
+	copy(inp[0x34:0x3F], "TETRIS")
+	return inp
+}
+
+func fixChecksum(inp []byte) {
+	var sum byte
+	for x := 0x34; x <= 0x4C; x++ {
+		sum = sum - inp[x] - 1
+	}
+	inp[0x4D] = sum
+}
+
+func TestParseHeaderWithOptionsStrictAcceptsValidHeader(t *testing.T) {
+	inp := validHeader()
+	fixChecksum(inp)
+
+	var header CartridgeHeader
+	assert.NoError(t, ParseHeaderWithOptions(inp, &header, ParseHeaderOptions{Strict: true}))
+}
+
+func TestParseHeaderWithOptionsStrictReportsEveryAnomaly(t *testing.T) {
+	inp := validHeader()
+	inp[0x04] = 0x00              // Corrupt logo
+	inp[0x36] = 'a'               // Lowercase title
+	copy(inp[0x44:0x46], "0\x00") // One-character licensee code
+	inp[0x4B] = 0x33
+	inp[0x47] = 0x04 // Unknown cartridge type
+	inp[0x48] = 0x09 // Unknown ROM size
+
+	var header CartridgeHeader
+	err := ParseHeaderWithOptions(inp, &header, ParseHeaderOptions{Strict: true})
+
+	var kinds []AnomalyKind
+	for _, a := range HeaderAnomalies(err) {
+		kinds = append(kinds, a.Kind)
+	}
+	assert.Equal(t, []AnomalyKind{AnomalyHeaderChecksum, AnomalyLogo, AnomalyTitle, AnomalyLicenseeCode, AnomalyCartridgeType, AnomalyROMSize}, kinds)
+	assert.True(t, errors.Is(err, ErrHeaderChecksumInvalid))
+	assert.Equal(t, "TEaRIS", header.Title)
+}
+
+func TestParseHeaderWithOptionsLenientOnlyChecksChecksum(t *testing.T) {
+	inp := validHeader()
+	inp[0x47] = 0x04
+	fixChecksum(inp)
+
+	var header CartridgeHeader
+	assert.NoError(t, ParseHeaderWithOptions(inp, &header, ParseHeaderOptions{}))
+}
+
+func TestParseHeaderReadsTwoCharacterLicenseeCode(t *testing.T) {
+	inp := validHeader()
+	copy(inp[0x44:0x46], "01")
+	fixChecksum(inp)
+
+	var header CartridgeHeader
+	assert.NoError(t, ParseHeader(inp, &header))
+	assert.Equal(t, "01", header.NewLicenseeCode)
+}
@@ -0,0 +1,142 @@
+package save
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrSaveTooSmall is returned when a save file is smaller than the cartridge RAM it should contain.
+var ErrSaveTooSmall error = errors.New("save data is smaller than the cartridge RAM size")
+
+// ErrRTCFooterInvalid is returned when RTC footer data is not a recognized size.
+var ErrRTCFooterInvalid error = errors.New("RTC footer is not 44 or 48 bytes long")
+
+// Sizes of the RTC footer appended to MBC3 saves by VBA-M, BGB, SameBoy and others.
+// Older VBA builds wrote a 32-bit timestamp, giving the legacy 44-byte variant.
+const (
+	RTCFooterSize       = 48
+	LegacyRTCFooterSize = 44
+)
+
+// FlashcartSaveSize is the minimum size of a save file used by flashcarts such as the EverDrive and EZ-Flash,
+// which dump the whole 32KB SRAM chip regardless of how much RAM the game declares.
+const FlashcartSaveSize = 32 * 1024
+
+// An RTCFooter holds the MBC3 real-time clock state stored after the cartridge RAM in emulator save files.
+type RTCFooter struct {
+	// The live clock registers: seconds, minutes, hours, day counter low, day counter high/flags.
+	Registers [5]uint8
+
+	// The latched copies of the clock registers, in the same order.
+	Latched [5]uint8
+
+	// The host UNIX time at which the save was written, used to advance the clock on load.
+	Timestamp int64
+}
+
+// ParseRTCFooter decodes a 44- or 48-byte RTC footer.
+// Returns ErrRTCFooterInvalid if the data is not one of those sizes.
+func ParseRTCFooter(inp []byte) (RTCFooter, error) {
+	var footer RTCFooter
+	if len(inp) != RTCFooterSize && len(inp) != LegacyRTCFooterSize {
+		return footer, ErrRTCFooterInvalid
+	}
+
+	// Each register is stored as a 32-bit little-endian value, live registers first.
+	for i := 0; i < 5; i++ {
+		footer.Registers[i] = uint8(binary.LittleEndian.Uint32(inp[i*4:]))
+		footer.Latched[i] = uint8(binary.LittleEndian.Uint32(inp[20+i*4:]))
+	}
+
+	if len(inp) == RTCFooterSize {
+		footer.Timestamp = int64(binary.LittleEndian.Uint64(inp[40:48]))
+	} else {
+		footer.Timestamp = int64(binary.LittleEndian.Uint32(inp[40:44]))
+	}
+	return footer, nil
+}
+
+// Bytes encodes the footer in the 48-byte format.
+func (f RTCFooter) Bytes() []byte {
+	out := make([]byte, RTCFooterSize)
+	for i := 0; i < 5; i++ {
+		binary.LittleEndian.PutUint32(out[i*4:], uint32(f.Registers[i]))
+		binary.LittleEndian.PutUint32(out[20+i*4:], uint32(f.Latched[i]))
+	}
+	binary.LittleEndian.PutUint64(out[40:], uint64(f.Timestamp))
+	return out
+}
+
+// A Layout describes how cartridge RAM is laid out in a save file.
+type Layout uint8
+
+// Values for Layout
+const (
+	// Exactly the cartridge RAM, with nothing appended. This is what real cartridges contain.
+	LayoutRaw Layout = iota
+	// The cartridge RAM followed by an RTC footer (if the cartridge has a clock), as used by most emulators.
+	LayoutEmulator
+	// The cartridge RAM padded with 0xFF to at least FlashcartSaveSize. Flashcarts keep the RTC elsewhere, so it is dropped.
+	LayoutFlashcart
+)
+
+func (l Layout) String() string {
+	return [...]string{"raw", "emulator", "flashcart"}[l]
+}
+
+// ParseLayout returns the Layout with the specified name, as returned by Layout.String.
+func ParseLayout(name string) (Layout, error) {
+	for _, l := range []Layout{LayoutRaw, LayoutEmulator, LayoutFlashcart} {
+		if l.String() == name {
+			return l, nil
+		}
+	}
+	return LayoutRaw, fmt.Errorf("unknown save layout %q", name)
+}
+
+// Split separates a save file in any supported layout into the cartridge RAM and the RTC footer (if present).
+// ramSize is the size in bytes of the cartridge RAM. Any other trailing data (such as flashcart padding) is discarded.
+// The returned RAM slice aliases inp.
+func Split(inp []byte, ramSize int) ([]byte, *RTCFooter, error) {
+	if len(inp) < ramSize {
+		return nil, nil, ErrSaveTooSmall
+	}
+
+	sram := inp[:ramSize]
+	rest := inp[ramSize:]
+	if len(rest) == RTCFooterSize || len(rest) == LegacyRTCFooterSize {
+		footer, err := ParseRTCFooter(rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sram, &footer, nil
+	}
+	return sram, nil, nil
+}
+
+// Merge builds a save file in the specified layout from cartridge RAM and an optional RTC footer.
+func Merge(sram []byte, rtc *RTCFooter, layout Layout) []byte {
+	out := append([]byte(nil), sram...)
+	switch layout {
+	case LayoutEmulator:
+		if rtc != nil {
+			out = append(out, rtc.Bytes()...)
+		}
+	case LayoutFlashcart:
+		for len(out) < FlashcartSaveSize {
+			out = append(out, 0xFF)
+		}
+	}
+	return out
+}
+
+// Convert converts a save file in any supported layout to the specified layout.
+// ramSize is the size in bytes of the cartridge RAM.
+func Convert(inp []byte, ramSize int, layout Layout) ([]byte, error) {
+	sram, rtc, err := Split(inp, ramSize)
+	if err != nil {
+		return nil, err
+	}
+	return Merge(sram, rtc, layout), nil
+}
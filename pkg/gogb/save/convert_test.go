@@ -0,0 +1,68 @@
+package save
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTCFooterRoundTrips(t *testing.T) {
+	footer := RTCFooter{
+		Registers: [5]uint8{59, 30, 12, 0xFF, 0x01},
+		Latched:   [5]uint8{58, 30, 12, 0xFF, 0x01},
+		Timestamp: 1600000000,
+	}
+	parsed, err := ParseRTCFooter(footer.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, footer, parsed)
+}
+
+func TestParseRTCFooterAcceptsLegacyFormat(t *testing.T) {
+	footer := RTCFooter{Registers: [5]uint8{1, 2, 3, 4, 5}, Timestamp: 1234}
+	parsed, err := ParseRTCFooter(footer.Bytes()[:LegacyRTCFooterSize])
+	assert.NoError(t, err)
+	assert.Equal(t, footer, parsed)
+}
+
+func TestSplitFindsRTCFooter(t *testing.T) {
+	sram := bytes.Repeat([]byte{0xAB}, 8192)
+	footer := RTCFooter{Registers: [5]uint8{1, 2, 3, 4, 5}}
+
+	gotRAM, gotRTC, err := Split(append(sram, footer.Bytes()...), len(sram))
+	assert.NoError(t, err)
+	assert.Equal(t, sram, gotRAM)
+	assert.Equal(t, &footer, gotRTC)
+}
+
+func TestSplitFailsIfSaveTooSmall(t *testing.T) {
+	_, _, err := Split(make([]byte, 100), 8192)
+	assert.Equal(t, ErrSaveTooSmall, err)
+}
+
+func TestConvertEmulatorToFlashcart(t *testing.T) {
+	sram := bytes.Repeat([]byte{0xAB}, 8192)
+	footer := RTCFooter{Registers: [5]uint8{1, 2, 3, 4, 5}}
+
+	out, err := Convert(append(sram, footer.Bytes()...), len(sram), LayoutFlashcart)
+	assert.NoError(t, err)
+	assert.Len(t, out, FlashcartSaveSize)
+	assert.Equal(t, sram, out[:8192])
+	assert.Equal(t, byte(0xFF), out[8192])
+}
+
+func TestConvertFlashcartToRaw(t *testing.T) {
+	sram := bytes.Repeat([]byte{0xAB}, 8192)
+	out, err := Convert(Merge(sram, nil, LayoutFlashcart), len(sram), LayoutRaw)
+	assert.NoError(t, err)
+	assert.Equal(t, sram, out)
+}
+
+func TestParseLayout(t *testing.T) {
+	l, err := ParseLayout("emulator")
+	assert.NoError(t, err)
+	assert.Equal(t, LayoutEmulator, l)
+
+	_, err = ParseLayout("bogus")
+	assert.Error(t, err)
+}
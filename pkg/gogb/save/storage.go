@@ -0,0 +1,250 @@
+package save
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotFound is returned by a Storage when no data has been saved under the requested name.
+var ErrNotFound error = errors.New("save data not found")
+
+// A Kind identifies the type of data being persisted.
+type Kind uint8
+
+// Values for Kind
+const (
+	// Battery-backed cartridge RAM.
+	KindBattery Kind = iota
+	// Real-time clock registers for cartridges with a timer.
+	KindRTC
+	// A full machine savestate.
+	KindState
+)
+
+// Ext returns the file extension conventionally used for this kind of data.
+// Unknown kinds use ".kindN".
+func (k Kind) Ext() string {
+	switch k {
+	case KindBattery:
+		return ".sav"
+	case KindRTC:
+		return ".rtc"
+	case KindState:
+		return ".state"
+	default:
+		return fmt.Sprintf(".kind%d", uint8(k))
+	}
+}
+
+func (k Kind) String() string {
+	switch k {
+	case KindBattery:
+		return "Battery"
+	case KindRTC:
+		return "RTC"
+	case KindState:
+		return "State"
+	default:
+		return fmt.Sprintf("Unknown(%d)", uint8(k))
+	}
+}
+
+// A Storage persists battery saves, RTC data and savestates.
+// Data is keyed by a name (usually derived from the ROM) and a Kind.
+// Embedders can implement Storage to keep data somewhere other than the filesystem.
+type Storage interface {
+	// Load returns the data stored for the given name and kind.
+	// Returns ErrNotFound if nothing has been stored.
+	Load(name string, kind Kind) ([]byte, error)
+
+	// Store replaces the data stored for the given name and kind.
+	Store(name string, kind Kind, data []byte) error
+}
+
+// A FileStorage is a Storage that keeps each item in a file named after the item with the Kind's extension.
+type FileStorage struct {
+	// The directory to store files in. If empty, names are treated as paths,
+	// so a name of "roms/tetris" stores battery RAM in "roms/tetris.sav".
+	Dir string
+}
+
+// NewFileStorage creates a FileStorage that keeps files in the specified directory.
+func NewFileStorage(dir string) *FileStorage {
+	return &FileStorage{Dir: dir}
+}
+
+// Path returns the path of the file used for the given name and kind.
+func (s *FileStorage) Path(name string, kind Kind) string {
+	return filepath.Join(s.Dir, name+kind.Ext())
+}
+
+// BackupPath returns the path of the rolling backup kept for the given name and kind.
+func (s *FileStorage) BackupPath(name string, kind Kind) string {
+	return s.Path(name, kind) + ".bak"
+}
+
+// SumPath returns the path of the checksum file Store writes next to the file at path.
+// The checksum is kept in a separate file so the data files keep the raw format other emulators expect.
+func SumPath(path string) string {
+	return path + ".sum"
+}
+
+// Load reads the file for the given name and kind.
+// Each file written by Store has a checksum file recording its length and CRC-32. If the file is missing,
+// unreadable, or doesn't match its checksum (because it was truncated or corrupted outside of Store), the
+// backup written by the previous Store is returned instead, provided it matches its own checksum.
+// Files without a checksum, such as saves copied from another emulator, are trusted as they are.
+// Returns ErrNotFound if neither file exists.
+func (s *FileStorage) Load(name string, kind Kind) ([]byte, error) {
+	path, backupPath := s.Path(name, kind), s.BackupPath(name, kind)
+	data, err := ioutil.ReadFile(path)
+	if err == nil && verifySum(path, data) {
+		return data, nil
+	}
+	backup, backupErr := ioutil.ReadFile(backupPath)
+	if backupErr == nil && verifySum(backupPath, backup) {
+		return backup, nil
+	}
+
+	// Neither file is intact; damaged data is still better than nothing
+	if err == nil {
+		return data, nil
+	}
+	if backupErr == nil {
+		return backup, nil
+	}
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return nil, err
+}
+
+// Store writes the file for the given name and kind, and its checksum file, creating the directory if needed.
+// Each file is written to a temporary file which is synced and then renamed over the original,
+// so a crash mid-write never leaves a partially written file behind.
+// The previous contents are kept as a backup (see BackupPath), unless they no longer match their
+// checksum, in which case the existing backup is kept instead.
+func (s *FileStorage) Store(name string, kind Kind, data []byte) error {
+	path, backupPath := s.Path(name, kind), s.BackupPath(name, kind)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if current, err := ioutil.ReadFile(path); err == nil && verifySum(path, current) {
+		if err := os.Rename(path, backupPath); err != nil {
+			return err
+		}
+		err := os.Rename(SumPath(path), SumPath(backupPath))
+		if os.IsNotExist(err) {
+			err = os.Remove(SumPath(backupPath))
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if err := writeAtomic(path, data); err != nil {
+		return err
+	}
+	if err := writeAtomic(SumPath(path), sum(data)); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// sum returns the contents of the checksum file for data: its length and CRC-32, little-endian.
+func sum(data []byte) []byte {
+	out := make([]byte, 8)
+	binary.LittleEndian.PutUint32(out[0:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(out[4:], crc32.ChecksumIEEE(data))
+	return out
+}
+
+// verifySum returns false if the checksum file for path exists and doesn't match data.
+func verifySum(path string, data []byte) bool {
+	expected, err := ioutil.ReadFile(SumPath(path))
+	if os.IsNotExist(err) {
+		return true
+	}
+	return err == nil && bytes.Equal(expected, sum(data))
+}
+
+// writeAtomic writes data to a temporary file, syncs it and renames it over path.
+func writeAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	// Clean up the temp file if anything fails. After a successful rename this is a no-op.
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// syncDir syncs a directory, so the renames made in it survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+type memoryKey struct {
+	name string
+	kind Kind
+}
+
+// A MemoryStorage is a Storage that keeps everything in memory. It is safe for concurrent use.
+type MemoryStorage struct {
+	mu    sync.Mutex
+	items map[memoryKey][]byte
+}
+
+// NewMemoryStorage creates an empty MemoryStorage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{items: make(map[memoryKey][]byte)}
+}
+
+// Load returns a copy of the data stored for the given name and kind.
+// Returns ErrNotFound if nothing has been stored.
+func (s *MemoryStorage) Load(name string, kind Kind) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.items[memoryKey{name, kind}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Store saves a copy of the data for the given name and kind.
+func (s *MemoryStorage) Store(name string, kind Kind, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[memoryKey{name, kind}] = append([]byte(nil), data...)
+	return nil
+}
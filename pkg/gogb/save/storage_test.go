@@ -0,0 +1,149 @@
+package save
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tempStorage(t *testing.T) *FileStorage {
+	dir, err := ioutil.TempDir("", "gogb-save")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return NewFileStorage(dir)
+}
+
+func TestFileStorageRoundTrips(t *testing.T) {
+	s := tempStorage(t)
+	assert.NoError(t, s.Store("tetris", KindBattery, []byte{1, 2, 3}))
+
+	data, err := s.Load("tetris", KindBattery)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, data)
+	assert.FileExists(t, filepath.Join(s.Dir, "tetris.sav"))
+}
+
+func TestFileStorageReturnsErrNotFound(t *testing.T) {
+	s := tempStorage(t)
+	_, err := s.Load("tetris", KindRTC)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestFileStorageKeepsKindsSeparate(t *testing.T) {
+	s := tempStorage(t)
+	assert.NoError(t, s.Store("pokemon", KindBattery, []byte{1}))
+	assert.NoError(t, s.Store("pokemon", KindRTC, []byte{2}))
+
+	data, err := s.Load("pokemon", KindRTC)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{2}, data)
+}
+
+func TestMemoryStorageCopiesData(t *testing.T) {
+	s := NewMemoryStorage()
+	buf := []byte{1, 2, 3}
+	assert.NoError(t, s.Store("tetris", KindBattery, buf))
+	buf[0] = 0xFF
+
+	data, err := s.Load("tetris", KindBattery)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, data)
+
+	_, err = s.Load("tetris", KindState)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestFileStorageKeepsBackupOfPreviousSave(t *testing.T) {
+	s := tempStorage(t)
+	assert.NoError(t, s.Store("tetris", KindBattery, []byte{1, 2, 3}))
+	assert.NoError(t, s.Store("tetris", KindBattery, []byte{4, 5, 6}))
+
+	backup, err := ioutil.ReadFile(s.BackupPath("tetris", KindBattery))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, backup)
+}
+
+func TestFileStorageLeavesNoTempFiles(t *testing.T) {
+	s := tempStorage(t)
+	assert.NoError(t, s.Store("tetris", KindBattery, []byte{1, 2, 3}))
+
+	files, err := ioutil.ReadDir(s.Dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2, "the save and its checksum")
+}
+
+func TestFileStorageRecoversTruncatedSaveFromBackup(t *testing.T) {
+	s := tempStorage(t)
+	assert.NoError(t, s.Store("pokemon", KindBattery, []byte{1, 2, 3, 4}))
+	assert.NoError(t, s.Store("pokemon", KindBattery, []byte{5, 6, 7, 8}))
+
+	// Simulate a crash that truncated the save outside of Store
+	assert.NoError(t, ioutil.WriteFile(s.Path("pokemon", KindBattery), []byte{5, 6}, 0644))
+
+	data, err := s.Load("pokemon", KindBattery)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3, 4}, data)
+
+	// Saving again must not rotate the truncated file over the good backup
+	assert.NoError(t, s.Store("pokemon", KindBattery, []byte{9, 9, 9, 9}))
+	backup, err := ioutil.ReadFile(s.BackupPath("pokemon", KindBattery))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3, 4}, backup)
+}
+
+func TestFileStorageRecoversMissingSaveFromBackup(t *testing.T) {
+	s := tempStorage(t)
+	assert.NoError(t, s.Store("pokemon", KindBattery, []byte{1, 2}))
+	assert.NoError(t, s.Store("pokemon", KindBattery, []byte{3, 4}))
+	assert.NoError(t, os.Remove(s.Path("pokemon", KindBattery)))
+
+	data, err := s.Load("pokemon", KindBattery)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, data)
+}
+
+func TestFileStorageReturnsSmallerSave(t *testing.T) {
+	s := tempStorage(t)
+	assert.NoError(t, s.Store("pokemon", KindState, []byte{1, 2, 3, 4}))
+	assert.NoError(t, s.Store("pokemon", KindState, []byte{5, 6}))
+
+	data, err := s.Load("pokemon", KindState)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{5, 6}, data)
+
+	// The smaller save is rotated into the backup like any other
+	assert.NoError(t, s.Store("pokemon", KindState, []byte{7}))
+	backup, err := ioutil.ReadFile(s.BackupPath("pokemon", KindState))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{5, 6}, backup)
+}
+
+func TestFileStorageRecoversCorruptedSaveFromBackup(t *testing.T) {
+	s := tempStorage(t)
+	assert.NoError(t, s.Store("pokemon", KindBattery, []byte{1, 2}))
+	assert.NoError(t, s.Store("pokemon", KindBattery, []byte{3, 4}))
+	assert.NoError(t, ioutil.WriteFile(s.Path("pokemon", KindBattery), []byte{3, 5}, 0644))
+
+	data, err := s.Load("pokemon", KindBattery)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, data)
+}
+
+func TestFileStorageTrustsSavesWithoutChecksum(t *testing.T) {
+	s := tempStorage(t)
+	assert.NoError(t, ioutil.WriteFile(s.Path("tetris", KindBattery), []byte{1, 2, 3}, 0644))
+
+	data, err := s.Load("tetris", KindBattery)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, data)
+}
+
+func TestKindOfUnknownValue(t *testing.T) {
+	assert.Equal(t, "Unknown(7)", Kind(7).String())
+	assert.Equal(t, ".kind7", Kind(7).Ext())
+}
@@ -0,0 +1,38 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/anurse/gogb/pkg/gogb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuiltROMHasValidHeader(t *testing.T) {
+	rom := NewROMBuilder("TESTROM").
+		SetType(gogb.Mbc1RamBattery, 0x02).
+		Program(0x3E, 0x42, 0x18, 0xFE).
+		Build()
+
+	var header gogb.CartridgeHeader
+	assert.NoError(t, gogb.ParseHeader(rom[0x0100:0x0150], &header))
+	assert.Equal(t, "TESTROM", header.Title)
+	assert.Equal(t, gogb.CartridgeType(gogb.Mbc1RamBattery), header.Type)
+	assert.Equal(t, 8, header.RAMSize.KB())
+	assert.Equal(t, gogb.NintendoLogo[:], rom[0x0104:0x0134])
+	assert.Equal(t, []byte{0x3E, 0x42, 0x18, 0xFE}, rom[ProgramStart:ProgramStart+4])
+}
+
+func TestBuiltROMHasValidGlobalChecksum(t *testing.T) {
+	rom := NewROMBuilder("TESTROM").Program(0x76).Build()
+
+	var header gogb.CartridgeHeader
+	assert.NoError(t, gogb.ParseHeader(rom[0x0100:0x0150], &header))
+
+	var sum uint16
+	for idx, byt := range rom {
+		if idx != 0x014E && idx != 0x014F {
+			sum += uint16(byt)
+		}
+	}
+	assert.Equal(t, sum, header.GlobalChecksum)
+}